@@ -1,9 +1,22 @@
 package discordgo
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Component types for Discord's UI system
@@ -30,6 +43,7 @@ const (
 	ModalComponent                 ComponentType = 18  // v2 addition
 	TabsComponent                  ComponentType = 19  // v2 addition
 	AccordionComponent             ComponentType = 20  // v2 addition
+	LabelComponent                 ComponentType = 21  // v2 addition
 )
 
 // Base interface that all components must implement
@@ -40,17 +54,55 @@ type MessageComponent interface {
 
 // ===== BUILDER FACTORY =====
 
+// Errors recorded by builders, check them with errors.Is on Err()
+var (
+	ErrBuilderOverflow = errors.New("component limit exceeded")
+	ErrInvalidChild    = errors.New("invalid child component")
+)
+
+// builderError keeps the first error a builder runs into so chained calls
+// don't need to be checked one by one
+type builderError struct {
+	err error
+}
+
+func (be *builderError) record(err error) {
+	if be.err == nil {
+		be.err = err
+	}
+}
+
+// Err returns the first error recorded while building, if any
+func (be *builderError) Err() error {
+	return be.err
+}
+
 // Easy way to start building components
-type ComponentBuilder struct{}
+type ComponentBuilder struct {
+	translate func(key string) string
+}
 
 func NewBuilder() *ComponentBuilder {
 	return &ComponentBuilder{}
 }
 
+// Create a builder that treats button and text input labels, modal titles
+// and label texts as keys, passing them through translate
+func NewBuilderWithTranslator(translate func(key string) string) *ComponentBuilder {
+	return &ComponentBuilder{translate: translate}
+}
+
+func (cb *ComponentBuilder) text(key string) string {
+	if cb.translate == nil {
+		return key
+	}
+	return cb.translate(key)
+}
+
 func (cb *ComponentBuilder) Button(label string) *ButtonBuilder {
 	return &ButtonBuilder{
 		button: Button{
-			Label: label,
+			Label: cb.text(label),
 			Style: PrimaryButton,
 		},
 	}
@@ -60,6 +112,7 @@ func (cb *ComponentBuilder) SelectMenu(customID string) *SelectMenuBuilder {
 	return &SelectMenuBuilder{
 		menu: SelectMenu{
 			CustomID: customID,
+			MenuType: StringSelectMenu,
 		},
 	}
 }
@@ -68,7 +121,7 @@ func (cb *ComponentBuilder) TextInput(customID, label string) *TextInputBuilder
 	return &TextInputBuilder{
 		input: TextInput{
 			CustomID: customID,
-			Label:    label,
+			Label:    cb.text(label),
 			Style:    TextInputShort,
 		},
 	}
@@ -87,7 +140,7 @@ func (cb *ComponentBuilder) Modal(customID, title string) *ModalBuilder {
 	return &ModalBuilder{
 		modal: Modal{
 			CustomID: customID,
-			Title:    title,
+			Title:    cb.text(title),
 		},
 	}
 }
@@ -100,9 +153,85 @@ func (cb *ComponentBuilder) Tabs(customID string) *TabsBuilder {
 	}
 }
 
+func (cb *ComponentBuilder) Accordion(customID string) *AccordionBuilder {
+	return &AccordionBuilder{
+		accordion: Accordion{
+			CustomID: customID,
+		},
+	}
+}
+
+func (cb *ComponentBuilder) ButtonGroup() *ButtonGroupBuilder {
+	return &ButtonGroupBuilder{}
+}
+
+func (cb *ComponentBuilder) MediaGallery() *MediaGalleryBuilder {
+	return &MediaGalleryBuilder{}
+}
+
+func (cb *ComponentBuilder) Section() *SectionBuilder {
+	return &SectionBuilder{}
+}
+
+func (cb *ComponentBuilder) Separator() *SeparatorBuilder {
+	return &SeparatorBuilder{}
+}
+
+func (cb *ComponentBuilder) Layout() *LayoutBuilder {
+	return &LayoutBuilder{
+		ids: make(map[int]string),
+	}
+}
+
+func (cb *ComponentBuilder) Label(text string) *LabelBuilder {
+	return &LabelBuilder{
+		label: Label{
+			Text: cb.text(text),
+		},
+	}
+}
+
+// ===== POINTER CONSTRUCTORS =====
+
+// Decoded components are pointers; these constructors return the same shape
+// with the defaults the builders use, so they compare cleanly.
+
+func NewActionsRow(components ...MessageComponent) *ActionsRow {
+	return &ActionsRow{Components: components}
+}
+
+func NewButton() *Button {
+	return &Button{Style: PrimaryButton}
+}
+
+func NewSelectMenu() *SelectMenu {
+	return &SelectMenu{MenuType: StringSelectMenu}
+}
+
+func NewTextInput() *TextInput {
+	return &TextInput{Style: TextInputShort}
+}
+
+func NewModal() *Modal {
+	return &Modal{}
+}
+
+func NewSection() *Section {
+	return &Section{}
+}
+
+func NewTextDisplay(content string) *TextDisplay {
+	return &TextDisplay{Content: content}
+}
+
+func NewContainer(components ...MessageComponent) *Container {
+	return &Container{Components: components}
+}
+
 // ===== BUTTON BUILDER =====
 
 type ButtonBuilder struct {
+	builderError
 	button Button
 }
 
@@ -118,13 +247,19 @@ func (bb *ButtonBuilder) Success() *ButtonBuilder   { return bb.Style(SuccessBut
 func (bb *ButtonBuilder) Danger() *ButtonBuilder    { return bb.Style(DangerButton) }
 func (bb *ButtonBuilder) Premium() *ButtonBuilder   { return bb.Style(PremiumButton) }
 
+// Turn the button into a link button; link buttons can't have a custom ID
 func (bb *ButtonBuilder) Link(url string) *ButtonBuilder {
 	bb.button.Style = LinkButton
 	bb.button.URL = url
+	bb.button.CustomID = ""
 	return bb
 }
 
 func (bb *ButtonBuilder) CustomID(id string) *ButtonBuilder {
+	if bb.button.Style == LinkButton {
+		bb.record(fmt.Errorf("link button cannot have custom ID %q", id))
+		return bb
+	}
 	bb.button.CustomID = id
 	return bb
 }
@@ -143,6 +278,38 @@ func (bb *ButtonBuilder) Emoji(name, id string, animated bool) *ButtonBuilder {
 	return bb
 }
 
+var (
+	emojiResolverMu sync.RWMutex
+	emojiResolver   func(shortcode string) (ComponentEmoji, bool)
+)
+
+// Set the function EmojiShortcode uses to turn shortcodes like ":fire:"
+// into emojis. Passing nil removes it.
+func SetEmojiResolver(fn func(shortcode string) (ComponentEmoji, bool)) {
+	emojiResolverMu.Lock()
+	emojiResolver = fn
+	emojiResolverMu.Unlock()
+}
+
+// Set the emoji from a shortcode using the resolver registered with
+// SetEmojiResolver
+func (bb *ButtonBuilder) EmojiShortcode(code string) *ButtonBuilder {
+	emojiResolverMu.RLock()
+	resolve := emojiResolver
+	emojiResolverMu.RUnlock()
+	if resolve == nil {
+		bb.record(fmt.Errorf("no emoji resolver set for shortcode %q", code))
+		return bb
+	}
+	emoji, ok := resolve(code)
+	if !ok {
+		bb.record(fmt.Errorf("unknown emoji shortcode %q", code))
+		return bb
+	}
+	bb.button.Emoji = &emoji
+	return bb
+}
+
 // v2 enhancements
 func (bb *ButtonBuilder) Tooltip(text string) *ButtonBuilder {
 	bb.button.Tooltip = text
@@ -154,6 +321,12 @@ func (bb *ButtonBuilder) Badge(count int) *ButtonBuilder {
 	return bb
 }
 
+// Show a short text badge such as "NEW" instead of a count
+func (bb *ButtonBuilder) BadgeText(text string) *ButtonBuilder {
+	bb.button.BadgeText = text
+	return bb
+}
+
 func (bb *ButtonBuilder) Loading(loading bool) *ButtonBuilder {
 	bb.button.Loading = loading
 	return bb
@@ -164,6 +337,33 @@ func (bb *ButtonBuilder) Size(size ButtonSize) *ButtonBuilder {
 	return bb
 }
 
+// Sort key used by SortButtons; it is never sent to Discord
+func (bb *ButtonBuilder) SortKey(key string) *ButtonBuilder {
+	bb.button.sortKey = key
+	return bb
+}
+
+// Saved state of a ButtonBuilder, see Snapshot
+type ButtonSnapshot struct {
+	button Button
+	err    error
+}
+
+// Capture the in-progress button so it can be restored later
+func (bb *ButtonBuilder) Snapshot() ButtonSnapshot {
+	return ButtonSnapshot{
+		button: cloneComponent(bb.button).(Button),
+		err:    bb.err,
+	}
+}
+
+// Return the builder to the state captured by Snapshot
+func (bb *ButtonBuilder) Restore(snapshot ButtonSnapshot) *ButtonBuilder {
+	bb.button = cloneComponent(snapshot.button).(Button)
+	bb.err = snapshot.err
+	return bb
+}
+
 func (bb *ButtonBuilder) Build() Button {
 	return bb.button
 }
@@ -171,7 +371,23 @@ func (bb *ButtonBuilder) Build() Button {
 // ===== SELECT MENU BUILDER =====
 
 type SelectMenuBuilder struct {
-	menu SelectMenu
+	builderError
+	menu                 SelectMenu
+	truncateDescriptions bool
+}
+
+// Cut option descriptions added from now on down to Discord's 100
+// character limit instead of producing an invalid option
+func (smb *SelectMenuBuilder) TruncateDescriptions(truncate bool) *SelectMenuBuilder {
+	smb.truncateDescriptions = truncate
+	return smb
+}
+
+func (smb *SelectMenuBuilder) description(text string) string {
+	if smb.truncateDescriptions && utf8.RuneCountInString(text) > maxOptionDescriptionLength {
+		return string([]rune(text)[:maxOptionDescriptionLength])
+	}
+	return text
 }
 
 func (smb *SelectMenuBuilder) Placeholder(text string) *SelectMenuBuilder {
@@ -179,6 +395,13 @@ func (smb *SelectMenuBuilder) Placeholder(text string) *SelectMenuBuilder {
 	return smb
 }
 
+// Set a "please choose" placeholder. Options marked as default still start
+// selected; otherwise the menu shows the hint with nothing selected, which
+// satisfies the strict placeholder-or-default check.
+func (smb *SelectMenuBuilder) WithHint(placeholder string) *SelectMenuBuilder {
+	return smb.Placeholder(placeholder)
+}
+
 func (smb *SelectMenuBuilder) MinValues(min int) *SelectMenuBuilder {
 	smb.menu.MinValues = &min
 	return smb
@@ -189,11 +412,26 @@ func (smb *SelectMenuBuilder) MaxValues(max int) *SelectMenuBuilder {
 	return smb
 }
 
+// Unselect every option and drop the default users, roles and channels,
+// e.g. when re-rendering a menu after a change
+func (smb *SelectMenuBuilder) ClearDefaults() *SelectMenuBuilder {
+	for i := range smb.menu.Options {
+		smb.menu.Options[i].Default = false
+	}
+	smb.menu.DefaultValues = nil
+	return smb
+}
+
+// Allow exactly one selection
+func (smb *SelectMenuBuilder) Single() *SelectMenuBuilder {
+	return smb.MinValues(1).MaxValues(1)
+}
+
 func (smb *SelectMenuBuilder) AddOption(label, value, description string) *SelectMenuBuilder {
 	option := SelectMenuOption{
 		Label:       label,
 		Value:       value,
-		Description: description,
+		Description: smb.description(description),
 	}
 	smb.menu.Options = append(smb.menu.Options, option)
 	return smb
@@ -203,7 +441,7 @@ func (smb *SelectMenuBuilder) AddOptionWithEmoji(label, value, description strin
 	option := SelectMenuOption{
 		Label:       label,
 		Value:       value,
-		Description: description,
+		Description: smb.description(description),
 		Emoji:       &emoji,
 	}
 	smb.menu.Options = append(smb.menu.Options, option)
@@ -227,6 +465,97 @@ func (smb *SelectMenuBuilder) ChannelSelect(channelTypes ...ChannelType) *Select
 	return smb
 }
 
+// Names accepted by SelectMenuBuilder.ChannelTypesByName
+var channelTypeNames = map[string]ChannelType{
+	"text":                ChannelTypeGuildText,
+	"dm":                  ChannelTypeDM,
+	"voice":               ChannelTypeGuildVoice,
+	"group_dm":            ChannelTypeGroupDM,
+	"category":            ChannelTypeGuildCategory,
+	"news":                ChannelTypeGuildNews,
+	"announcement":        ChannelTypeGuildNews,
+	"store":               ChannelTypeGuildStore,
+	"news_thread":         ChannelTypeGuildNewsThread,
+	"announcement_thread": ChannelTypeGuildNewsThread,
+	"public_thread":       ChannelTypeGuildPublicThread,
+	"private_thread":      ChannelTypeGuildPrivateThread,
+	"stage":               ChannelTypeGuildStageVoice,
+	"directory":           ChannelTypeGuildDirectory,
+	"forum":               ChannelTypeGuildForum,
+	"media":               ChannelTypeGuildMedia,
+}
+
+// Make this a channel select limited to the named channel types, e.g.
+// "text" or "voice"
+func (smb *SelectMenuBuilder) ChannelTypesByName(names ...string) *SelectMenuBuilder {
+	types := make([]ChannelType, 0, len(names))
+	for _, name := range names {
+		t, ok := channelTypeNames[strings.ToLower(name)]
+		if !ok {
+			smb.record(fmt.Errorf("unknown channel type name %q", name))
+			continue
+		}
+		types = append(types, t)
+	}
+	return smb.ChannelSelect(types...)
+}
+
+// Preselect a channel, checking its type against the menu's channel types
+func (smb *SelectMenuBuilder) DefaultChannelTyped(id string, t ChannelType) *SelectMenuBuilder {
+	if smb.menu.MenuType != ChannelSelectMenu {
+		smb.record(fmt.Errorf("default channel %s requires a channel select menu", id))
+		return smb
+	}
+	if len(smb.menu.ChannelTypes) > 0 {
+		allowed := false
+		for _, ct := range smb.menu.ChannelTypes {
+			if ct == t {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			smb.record(fmt.Errorf("default channel %s has type %d, which the menu does not allow", id, t))
+			return smb
+		}
+	}
+	return smb.addDefault(id, SelectMenuDefaultValueChannel)
+}
+
+func (smb *SelectMenuBuilder) addDefault(id string, t SelectMenuDefaultValueType) *SelectMenuBuilder {
+	if smb.menu.MaxValues > 0 && len(smb.menu.DefaultValues) >= smb.menu.MaxValues {
+		smb.record(fmt.Errorf("%w: select menu allows at most %d default values", ErrBuilderOverflow, smb.menu.MaxValues))
+		return smb
+	}
+	smb.menu.DefaultValues = append(smb.menu.DefaultValues, SelectMenuDefaultValue{
+		ID:   id,
+		Type: t,
+	})
+	return smb
+}
+
+func (smb *SelectMenuBuilder) DefaultUser(id string) *SelectMenuBuilder {
+	return smb.addDefault(id, SelectMenuDefaultValueUser)
+}
+
+func (smb *SelectMenuBuilder) DefaultRole(id string) *SelectMenuBuilder {
+	return smb.addDefault(id, SelectMenuDefaultValueRole)
+}
+
+func (smb *SelectMenuBuilder) DefaultUsers(ids ...string) *SelectMenuBuilder {
+	for _, id := range ids {
+		smb.addDefault(id, SelectMenuDefaultValueUser)
+	}
+	return smb
+}
+
+func (smb *SelectMenuBuilder) DefaultChannels(ids ...string) *SelectMenuBuilder {
+	for _, id := range ids {
+		smb.addDefault(id, SelectMenuDefaultValueChannel)
+	}
+	return smb
+}
+
 // v2 enhancements
 func (smb *SelectMenuBuilder) Searchable(searchable bool) *SelectMenuBuilder {
 	smb.menu.Searchable = searchable
@@ -238,6 +567,12 @@ func (smb *SelectMenuBuilder) Grouped(grouped bool) *SelectMenuBuilder {
 	return smb
 }
 
+// Set the numeric component ID
+func (smb *SelectMenuBuilder) ID(id int) *SelectMenuBuilder {
+	smb.menu.ID = id
+	return smb
+}
+
 func (smb *SelectMenuBuilder) Build() SelectMenu {
 	return smb.menu
 }
@@ -245,6 +580,7 @@ func (smb *SelectMenuBuilder) Build() SelectMenu {
 // ===== TEXT INPUT BUILDER =====
 
 type TextInputBuilder struct {
+	builderError
 	input TextInput
 }
 
@@ -258,6 +594,15 @@ func (tib *TextInputBuilder) Value(text string) *TextInputBuilder {
 	return tib
 }
 
+// Prefill the value and raise MaxLength so the value fits
+func (tib *TextInputBuilder) ValueWithBounds(value string) *TextInputBuilder {
+	tib.input.Value = value
+	if n := utf8.RuneCountInString(value); tib.input.MaxLength < n {
+		tib.input.MaxLength = n
+	}
+	return tib
+}
+
 func (tib *TextInputBuilder) Required(required bool) *TextInputBuilder {
 	tib.input.Required = required
 	return tib
@@ -286,6 +631,7 @@ func (tib *TextInputBuilder) MaxLength(length int) *TextInputBuilder {
 // v2 enhancements
 func (tib *TextInputBuilder) Validation(pattern string) *TextInputBuilder {
 	tib.input.ValidationPattern = pattern
+	tib.input.compiled = &compiledPattern{source: pattern}
 	return tib
 }
 
@@ -301,16 +647,44 @@ func (tib *TextInputBuilder) Build() TextInput {
 // ===== ACTIONS ROW BUILDER =====
 
 type ActionsRowBuilder struct {
-	row ActionsRow
+	builderError
+	row        ActionsRow
+	buttonSize ButtonSize
 }
 
 func (arb *ActionsRowBuilder) AddComponent(component MessageComponent) *ActionsRowBuilder {
-	if len(arb.row.Components) < 5 {
-		arb.row.Components = append(arb.row.Components, component)
+	switch deref(component).(type) {
+	case Button, SelectMenu, TextInput:
+	default:
+		arb.record(fmt.Errorf("%w: actions row cannot contain %T", ErrInvalidChild, component))
+		return arb
+	}
+	if len(arb.row.Components) >= 5 {
+		arb.record(fmt.Errorf("%w: actions row can have maximum 5 components", ErrBuilderOverflow))
+		return arb
+	}
+	arb.row.Components = append(arb.row.Components, arb.sized(component))
+	return arb
+}
+
+// Give every button in the row the same size, both those already added and
+// those added later
+func (arb *ActionsRowBuilder) ButtonSize(size ButtonSize) *ActionsRowBuilder {
+	arb.buttonSize = size
+	for i, component := range arb.row.Components {
+		arb.row.Components[i] = arb.sized(component)
 	}
 	return arb
 }
 
+func (arb *ActionsRowBuilder) sized(component MessageComponent) MessageComponent {
+	if b, ok := deref(component).(Button); ok && arb.buttonSize != "" {
+		b.Size = arb.buttonSize
+		return b
+	}
+	return component
+}
+
 func (arb *ActionsRowBuilder) AddButton(button Button) *ActionsRowBuilder {
 	return arb.AddComponent(button)
 }
@@ -326,16 +700,22 @@ func (arb *ActionsRowBuilder) Build() ActionsRow {
 // ===== v2 MODAL BUILDER =====
 
 type ModalBuilder struct {
+	builderError
 	modal Modal
 }
 
 func (mb *ModalBuilder) AddComponent(component MessageComponent) *ModalBuilder {
+	if _, ok := deref(component).(Modal); ok {
+		mb.record(fmt.Errorf("%w: modals cannot be nested", ErrInvalidChild))
+		return mb
+	}
 	mb.modal.Components = append(mb.modal.Components, component)
 	return mb
 }
 
+// Add a text input wrapped in its own actions row, as Discord expects
 func (mb *ModalBuilder) AddTextInput(input TextInput) *ModalBuilder {
-	return mb.AddComponent(input)
+	return mb.AddComponent(ActionsRow{Components: []MessageComponent{input}})
 }
 
 func (mb *ModalBuilder) Size(size ModalSize) *ModalBuilder {
@@ -355,6 +735,7 @@ func (mb *ModalBuilder) Build() Modal {
 // ===== v2 TABS BUILDER =====
 
 type TabsBuilder struct {
+	builderError
 	tabs Tabs
 }
 
@@ -368,6 +749,18 @@ func (tb *TabsBuilder) AddTab(id, label string, content MessageComponent) *TabsB
 	return tb
 }
 
+// Add a tab only if its content passes validation
+func (tb *TabsBuilder) AddValidatedTab(id, label string, content MessageComponent) error {
+	if content == nil {
+		return fmt.Errorf("%w: tab %s has no content", ErrInvalidChild, id)
+	}
+	if err := validateTree(content); err != nil {
+		return fmt.Errorf("tab %s: %w", id, err)
+	}
+	tb.AddTab(id, label, content)
+	return nil
+}
+
 func (tb *TabsBuilder) DefaultTab(id string) *TabsBuilder {
 	tb.tabs.DefaultTab = id
 	return tb
@@ -377,6 +770,269 @@ func (tb *TabsBuilder) Build() Tabs {
 	return tb.tabs
 }
 
+// Like Build, but fails if no tabs were added or an error was recorded
+func (tb *TabsBuilder) BuildValidated() (Tabs, error) {
+	if tb.err != nil {
+		return tb.tabs, tb.err
+	}
+	if len(tb.tabs.TabList) == 0 {
+		return tb.tabs, fmt.Errorf("tabs %s must have at least 1 tab", tb.tabs.CustomID)
+	}
+	return tb.tabs, nil
+}
+
+// ===== v2 ACCORDION BUILDER =====
+
+type AccordionBuilder struct {
+	builderError
+	accordion Accordion
+}
+
+func (ab *AccordionBuilder) AddItem(id, title string, content MessageComponent) *AccordionBuilder {
+	item := AccordionItem{
+		ID:      id,
+		Title:   title,
+		Content: content,
+	}
+	ab.accordion.Items = append(ab.accordion.Items, item)
+	return ab
+}
+
+// Expand the item with the given ID initially
+func (ab *AccordionBuilder) Open(id string) *AccordionBuilder {
+	for i := range ab.accordion.Items {
+		if ab.accordion.Items[i].ID == id {
+			ab.accordion.Items[i].Open = true
+			return ab
+		}
+	}
+	ab.record(fmt.Errorf("accordion has no item %s", id))
+	return ab
+}
+
+func (ab *AccordionBuilder) Multiple(multiple bool) *AccordionBuilder {
+	ab.accordion.Multiple = multiple
+	return ab
+}
+
+func (ab *AccordionBuilder) Build() Accordion {
+	return ab.accordion
+}
+
+// Like Build, but fails if no items were added or an error was recorded
+func (ab *AccordionBuilder) BuildValidated() (Accordion, error) {
+	if ab.err != nil {
+		return ab.accordion, ab.err
+	}
+	if len(ab.accordion.Items) == 0 {
+		return ab.accordion, fmt.Errorf("accordion %s must have at least 1 item", ab.accordion.CustomID)
+	}
+	return ab.accordion, nil
+}
+
+// ===== v2 BUTTON GROUP BUILDER =====
+
+type ButtonGroupBuilder struct {
+	builderError
+	group ButtonGroup
+}
+
+func (bgb *ButtonGroupBuilder) AddButton(button Button) *ButtonGroupBuilder {
+	bgb.group.Buttons = append(bgb.group.Buttons, button)
+	return bgb
+}
+
+// Stack the buttons on top of each other instead of side by side
+func (bgb *ButtonGroupBuilder) Vertical() *ButtonGroupBuilder {
+	bgb.group.Vertical = true
+	return bgb
+}
+
+// Let buttons flow onto further lines when they don't fit
+func (bgb *ButtonGroupBuilder) Wrap(wrap bool) *ButtonGroupBuilder {
+	bgb.group.Wrap = wrap
+	return bgb
+}
+
+func (bgb *ButtonGroupBuilder) Build() ButtonGroup {
+	return bgb.group
+}
+
+// ===== v2 MEDIA GALLERY BUILDER =====
+
+type MediaGalleryBuilder struct {
+	builderError
+	gallery MediaGallery
+}
+
+// Options for MediaGalleryBuilder.AddItem
+type MediaGalleryItemOption func(*MediaGalleryItem)
+
+func WithSpoiler() MediaGalleryItemOption {
+	return func(item *MediaGalleryItem) {
+		item.Spoiler = true
+	}
+}
+
+func WithDescription(description string) MediaGalleryItemOption {
+	return func(item *MediaGalleryItem) {
+		item.Description = description
+	}
+}
+
+func (mgb *MediaGalleryBuilder) AddItem(url string, opts ...MediaGalleryItemOption) *MediaGalleryBuilder {
+	item := MediaGalleryItem{Media: UnfurledMediaItem{URL: url}}
+	for _, opt := range opts {
+		opt(&item)
+	}
+	mgb.gallery.Items = append(mgb.gallery.Items, item)
+	return mgb
+}
+
+func (mgb *MediaGalleryBuilder) Build() MediaGallery {
+	return mgb.gallery
+}
+
+// ===== v2 SECTION BUILDER =====
+
+// Discord allows 1 to 3 text displays in a section
+const maxSectionTexts = 3
+
+type SectionBuilder struct {
+	builderError
+	section Section
+}
+
+// Add a line of markdown text
+func (sb *SectionBuilder) AddText(content string) *SectionBuilder {
+	if len(sb.section.Components) >= maxSectionTexts {
+		sb.record(fmt.Errorf("%w: section can have maximum %d text displays", ErrBuilderOverflow, maxSectionTexts))
+		return sb
+	}
+	sb.section.Components = append(sb.section.Components, TextDisplay{Content: content})
+	return sb
+}
+
+// Set the button or thumbnail shown beside the text
+func (sb *SectionBuilder) Accessory(accessory MessageComponent) *SectionBuilder {
+	switch deref(accessory).(type) {
+	case Button, Thumbnail:
+	default:
+		sb.record(fmt.Errorf("%w: section accessory cannot be %T", ErrInvalidChild, accessory))
+		return sb
+	}
+	sb.section.Accessory = accessory
+	return sb
+}
+
+func (sb *SectionBuilder) Build() Section {
+	return sb.section
+}
+
+// ===== v2 SEPARATOR BUILDER =====
+
+type SeparatorBuilder struct {
+	builderError
+	separator Separator
+}
+
+func (sb *SeparatorBuilder) Small() *SeparatorBuilder {
+	sb.separator.Spacing = SeparatorSpacingSmall
+	return sb
+}
+
+func (sb *SeparatorBuilder) Large() *SeparatorBuilder {
+	sb.separator.Spacing = SeparatorSpacingLarge
+	return sb
+}
+
+// Draw a line instead of only adding space
+func (sb *SeparatorBuilder) Divider() *SeparatorBuilder {
+	sb.separator.Divider = true
+	return sb
+}
+
+func (sb *SeparatorBuilder) Build() Separator {
+	return sb.separator
+}
+
+// ===== LAYOUT BUILDER =====
+
+// Collects the top-level components of a message and makes sure no two
+// components anywhere in it share a numeric ID
+type LayoutBuilder struct {
+	builderError
+	components []MessageComponent
+	ids        map[int]string
+}
+
+// Add a top-level component. A component whose tree reuses an ID already
+// in the layout is left out and the collision recorded.
+func (lb *LayoutBuilder) Add(component MessageComponent) *LayoutBuilder {
+	index := len(lb.components)
+	added := make(map[int]string)
+	var err error
+	walkComponents(component, fmt.Sprintf("components[%d]", index), nil, func(path string, parent, c MessageComponent) bool {
+		id, _ := componentID(c)
+		if id == 0 {
+			return true
+		}
+		first, ok := lb.ids[id]
+		if !ok {
+			first, ok = added[id]
+		}
+		if ok {
+			err = fmt.Errorf("component ID %d at %s is already used at %s", id, path, first)
+			return false
+		}
+		added[id] = path
+		return true
+	})
+	if err != nil {
+		lb.record(err)
+		return lb
+	}
+	for id, path := range added {
+		lb.ids[id] = path
+	}
+	lb.components = append(lb.components, component)
+	return lb
+}
+
+func (lb *LayoutBuilder) Build() []MessageComponent {
+	return lb.components
+}
+
+// ===== v2 LABEL BUILDER =====
+
+type LabelBuilder struct {
+	builderError
+	label Label
+}
+
+func (lb *LabelBuilder) Description(text string) *LabelBuilder {
+	lb.label.Description = text
+	return lb
+}
+
+// Set the input the label describes, replacing any previous one
+func (lb *LabelBuilder) SetComponent(component MessageComponent) *LabelBuilder {
+	switch deref(component).(type) {
+	case nil:
+		lb.record(fmt.Errorf("%w: label needs a component", ErrInvalidChild))
+		return lb
+	case Modal, Label:
+		lb.record(fmt.Errorf("%w: label cannot contain %T", ErrInvalidChild, component))
+		return lb
+	}
+	lb.label.Component = component
+	return lb
+}
+
+func (lb *LabelBuilder) Build() Label {
+	return lb.label
+}
+
 // ===== QUICK HELPERS =====
 
 // Create multiple buttons in one row
@@ -397,6 +1053,27 @@ func QuickButton(label, customID string, style ButtonStyle) Button {
 	}
 }
 
+// Create a simple button with a numeric component ID
+func QuickButtonWithID(label, customID string, style ButtonStyle, id int) Button {
+	button := QuickButton(label, customID, style)
+	button.ID = id
+	return button
+}
+
+// Label used by StubButton until the caller sets a real one
+const StubButtonLabel = "…"
+
+// Create a disabled stand-in for a button when only its custom ID is known,
+// e.g. from an interaction
+func StubButton(customID string) Button {
+	return Button{
+		Label:    StubButtonLabel,
+		CustomID: customID,
+		Style:    SecondaryButton,
+		Disabled: true,
+	}
+}
+
 // Create a select menu with options
 func QuickSelectMenu(customID, placeholder string, options ...SelectMenuOption) SelectMenu {
 	return SelectMenu{
@@ -407,6 +1084,13 @@ func QuickSelectMenu(customID, placeholder string, options ...SelectMenuOption)
 	}
 }
 
+// Create a select menu with options and a numeric component ID
+func QuickSelectMenuWithID(customID, placeholder string, id int, options ...SelectMenuOption) SelectMenu {
+	menu := QuickSelectMenu(customID, placeholder, options...)
+	menu.ID = id
+	return menu
+}
+
 // Create a select menu option
 func QuickOption(label, value, description string) SelectMenuOption {
 	return SelectMenuOption{
@@ -416,12 +1100,109 @@ func QuickOption(label, value, description string) SelectMenuOption {
 	}
 }
 
-// Create a confirmation dialog with Yes/No buttons
-func QuickConfirmDialog(customID string) ActionsRow {
-	return QuickButtons(
-		QuickButton("Yes", customID+"_yes", SuccessButton),
-		QuickButton("No", customID+"_no", DangerButton),
-	)
+// Offer the same choices as button rows when there are at most threshold
+// of them, or as a select menu in a row otherwise. Buttons use the custom
+// ID customID+"_"+value, the select menu customID itself.
+func ChoicesToComponents(customID string, options []SelectMenuOption, threshold int) []MessageComponent {
+	if len(options) > threshold {
+		menu := QuickSelectMenu(customID, "", options...)
+		return []MessageComponent{ActionsRow{Components: []MessageComponent{menu}}}
+	}
+	buttons := make([]Button, len(options))
+	for i, option := range options {
+		buttons[i] = QuickButton(option.Label, customID+"_"+option.Value, SecondaryButton)
+		buttons[i].Emoji = option.Emoji
+	}
+	var components []MessageComponent
+	for _, row := range (ButtonGroup{Buttons: buttons}).ToRows() {
+		components = append(components, row)
+	}
+	return components
+}
+
+// Create a confirmation dialog with Yes/No buttons
+func QuickConfirmDialog(customID string) ActionsRow {
+	return ConfirmDialog(customID, ConfirmOptions{})
+}
+
+// Appearance of the two ConfirmDialog buttons. Zero fields fall back to
+// the QuickConfirmDialog look: a green "Yes" and a red "No".
+type ConfirmOptions struct {
+	YesLabel string
+	NoLabel  string
+	YesStyle ButtonStyle
+	NoStyle  ButtonStyle
+	YesEmoji *ComponentEmoji
+	NoEmoji  *ComponentEmoji
+}
+
+// Create a confirmation dialog with custom labels, styles and emojis. The
+// buttons use the custom IDs customID+"_yes" and customID+"_no".
+func ConfirmDialog(customID string, opts ConfirmOptions) ActionsRow {
+	if opts.YesLabel == "" {
+		opts.YesLabel = "Yes"
+	}
+	if opts.NoLabel == "" {
+		opts.NoLabel = "No"
+	}
+	if opts.YesStyle == 0 {
+		opts.YesStyle = SuccessButton
+	}
+	if opts.NoStyle == 0 {
+		opts.NoStyle = DangerButton
+	}
+	yes := QuickButton(opts.YesLabel, customID+"_yes", opts.YesStyle)
+	yes.Emoji = opts.YesEmoji
+	no := QuickButton(opts.NoLabel, customID+"_no", opts.NoStyle)
+	no.Emoji = opts.NoEmoji
+	return QuickButtons(yes, no)
+}
+
+// Group options by key, e.g. a category, to spread them over several menus.
+// Options keep their relative order within each group.
+func SplitOptions(options []SelectMenuOption, key func(SelectMenuOption) string) map[string][]SelectMenuOption {
+	groups := make(map[string][]SelectMenuOption)
+	for _, option := range options {
+		k := key(option)
+		groups[k] = append(groups[k], option)
+	}
+	return groups
+}
+
+// Create a Yes/No dialog whose Yes button starts disabled, along with the
+// time it should be re-enabled
+func QuickConfirmWithDelay(customID string, enableAfter time.Duration) (ActionsRow, time.Time) {
+	yes := QuickButton("Yes", customID+"_yes", SuccessButton)
+	yes.Disabled = true
+	row := QuickButtons(yes, QuickButton("No", customID+"_no", DangerButton))
+	return row, time.Now().Add(enableAfter)
+}
+
+// Create an on/off toggle button, green when on and grey when off
+func QuickToggle(customID, label string, on bool) Button {
+	style := SecondaryButton
+	if on {
+		style = SuccessButton
+	}
+	return QuickButton(label, customID+"_toggle", style)
+}
+
+// Return a copy of the button with the style following its current one in
+// styles, wrapping around at the end. A button whose style isn't listed
+// gets the first one.
+func CycleButtonStyle(b Button, styles ...ButtonStyle) Button {
+	if len(styles) == 0 {
+		return b
+	}
+	next := styles[0]
+	for i, style := range styles {
+		if style == b.Style {
+			next = styles[(i+1)%len(styles)]
+			break
+		}
+	}
+	b.Style = next
+	return b
 }
 
 // Create pagination buttons
@@ -447,9 +1228,527 @@ func QuickPagination(customID string, currentPage, totalPages int) ActionsRow {
 	return QuickButtons(buttons...)
 }
 
+// Create just the previous and next buttons of QuickPagination, disabled
+// when there is no page in that direction
+func QuickPrevNext(customID string, hasPrev, hasNext bool) ActionsRow {
+	prev := QuickButton("◀️", customID+"_prev", SecondaryButton)
+	prev.Disabled = !hasPrev
+	next := QuickButton("▶️", customID+"_next", SecondaryButton)
+	next.Disabled = !hasNext
+	return QuickButtons(prev, next)
+}
+
+// Create a "Load more" button carrying a pagination cursor in its custom
+// ID as prefix+":more:"+cursor. Read it back with ParseLoadMore. A cursor
+// that pushes the ID over Discord's 100 character limit is an error.
+func LoadMoreButton(prefix, cursor string) (Button, error) {
+	customID := prefix + ":more:" + cursor
+	if n := utf8.RuneCountInString(customID); n > maxCustomIDLength {
+		return Button{}, fmt.Errorf("load more custom ID is %d characters, maximum is %d", n, maxCustomIDLength)
+	}
+	return QuickButton("Load more", customID, SecondaryButton), nil
+}
+
+// Extract the cursor from the custom ID of a LoadMoreButton with the given
+// prefix
+func ParseLoadMore(prefix, customID string) (cursor string, ok bool) {
+	if !strings.HasPrefix(customID, prefix+":more:") {
+		return "", false
+	}
+	return strings.TrimPrefix(customID, prefix+":more:"), true
+}
+
+var keycapEmojis = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣", "9️⃣", "🔟"}
+
+// Create up to 10 buttons labeled 1️⃣ to 🔟, five to a row, e.g. for quiz
+// answers. Their custom IDs are customID+"_1", customID+"_2" and so on.
+func QuickNumberButtons(customID string, count int) []ActionsRow {
+	if count > len(keycapEmojis) {
+		count = len(keycapEmojis)
+	}
+	var buttons []Button
+	for i := 0; i < count; i++ {
+		buttons = append(buttons, QuickButton(keycapEmojis[i], fmt.Sprintf("%s_%d", customID, i+1), SecondaryButton))
+	}
+	return ButtonGroup{Buttons: buttons}.ToRows()
+}
+
+// Create a multi-step wizard as tabs in the given order, starting on the
+// first step. Rather than returning Tabs alone and panicking, it returns
+// an error and empty Tabs if a step in order is missing from steps.
+func QuickWizard(customID string, steps map[string]MessageComponent, order []string) (Tabs, error) {
+	builder := NewBuilder().Tabs(customID)
+	for i, id := range order {
+		content, ok := steps[id]
+		if !ok {
+			return Tabs{}, fmt.Errorf("%w: step %s is missing from steps", ErrInvalidChild, id)
+		}
+		builder.AddTab(id, fmt.Sprintf("Step %d", i+1), content)
+	}
+	if len(order) > 0 {
+		builder.DefaultTab(order[0])
+	}
+	return builder.Build(), nil
+}
+
+// A question and its answer for QuickFAQ
+type FAQEntry struct {
+	Question string
+	Answer   string
+}
+
+// Create an accordion with one item per entry, titled by the question and
+// showing the answer as text. Items get the IDs "1", "2" and so on.
+func QuickFAQ(customID string, entries []FAQEntry) Accordion {
+	accordion := Accordion{CustomID: customID}
+	for i, entry := range entries {
+		accordion.Items = append(accordion.Items, AccordionItem{
+			ID:      strconv.Itoa(i + 1),
+			Title:   entry.Question,
+			Content: TextDisplay{Content: entry.Answer},
+		})
+	}
+	return accordion
+}
+
+const progressBarWidth = 10
+
+// Render a text progress bar inside a container, e.g. "█████░░░░░ 50%"
+func QuickProgressBar(label string, percent float64) Container {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	filled := int(math.Round(percent / 100 * progressBarWidth))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+
+	return Container{
+		Components: []MessageComponent{
+			TextDisplay{Content: fmt.Sprintf("%s\n%s %.0f%%", label, bar, percent)},
+		},
+	}
+}
+
+// Create a caption followed by a divider line
+func QuickLabeledSeparator(label string) []MessageComponent {
+	return []MessageComponent{
+		TextDisplay{Content: label},
+		Separator{Divider: true},
+	}
+}
+
+// Create a section header: the title as a heading with a thumbnail beside
+// it. A thumbnail URL that isn't http(s):// or attachment:// is an error.
+func QuickHeader(title string, thumbnailURL string) (Section, error) {
+	media := UnfurledMediaItem{URL: thumbnailURL}
+	if err := validateMediaItem(media); err != nil {
+		return Section{}, fmt.Errorf("thumbnail %w", err)
+	}
+	return Section{
+		Components: []MessageComponent{
+			TextDisplay{Content: "## " + title},
+		},
+		Accessory: Thumbnail{Media: media},
+	}, nil
+}
+
+// ===== PAYLOAD HELPERS =====
+
+// Marshal an interaction response that only the invoking user can see
+func EphemeralResponse(components ...MessageComponent) ([]byte, error) {
+	if components == nil {
+		components = []MessageComponent{}
+	}
+	return Marshal(InteractionResponse{
+		Type: InteractionResponseChannelMessageWithSource,
+		Data: &InteractionResponseData{
+			Components: components,
+			Flags:      MessageFlagsEphemeral,
+		},
+	})
+}
+
+// Webhook body fragment holding the components, to be merged with the
+// content, embeds and other fields of a webhook execution
+func WebhookComponents(components ...MessageComponent) map[string]interface{} {
+	if components == nil {
+		components = []MessageComponent{}
+	}
+	return map[string]interface{}{"components": components}
+}
+
+// Marshal the components field of a message edit that turns old into new.
+// Discord replaces the components of an edited message as a whole, so the
+// result is the full new array, or nil when nothing changed and the edit
+// can leave components out.
+func EditComponents(old, new []MessageComponent) ([]byte, error) {
+	if len(old) == len(new) {
+		unchanged := true
+		for i := range new {
+			if !topLevelEqual(old[i], new[i]) {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return nil, nil
+		}
+	}
+	return marshalComponents(new)
+}
+
+// Marshal the smallest diff that turns the top-level components old into
+// new. If both have the same length and component types, the result is an
+// object holding only the changed components keyed by index, e.g.
+// {"1":{...}}; otherwise it is the full new array. Discord doesn't accept
+// the object form in a message edit, so it is only useful to callers that
+// apply the diff to their own copy of a message; send EditComponents to
+// the API instead.
+func EditPatch(old, new []MessageComponent) ([]byte, error) {
+	if len(old) != len(new) {
+		return marshalComponents(new)
+	}
+	for i := range new {
+		if old[i] == nil || new[i] == nil || old[i].Type() != new[i].Type() {
+			return marshalComponents(new)
+		}
+	}
+	changed := make(map[string]MessageComponent)
+	for i := range new {
+		if !topLevelEqual(old[i], new[i]) {
+			changed[strconv.Itoa(i)] = new[i]
+		}
+	}
+	return Marshal(changed)
+}
+
+// topLevelEqual is componentsEqual that also accepts nil components.
+func topLevelEqual(a, b MessageComponent) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return componentsEqual(a, b)
+}
+
+func marshalComponents(components []MessageComponent) ([]byte, error) {
+	if components == nil {
+		components = []MessageComponent{}
+	}
+	return Marshal(components)
+}
+
+// Marshal a tree into JSON that upstream discordgo's component unmarshaler
+// accepts. Fields upstream doesn't model, such as button tooltips or text
+// input patterns, are stripped. Component types it doesn't know, like tabs
+// or button groups, are an error.
+func ToDiscordgo(c MessageComponent) (json.RawMessage, error) {
+	var err error
+	walkComponents(c, "", nil, func(path string, parent, c MessageComponent) bool {
+		if t := c.Type(); t > SeparatorComponent && t != ContainerComponent {
+			err = fmt.Errorf("component type %d has no discordgo equivalent", t)
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(Map(c, upstreamComponent))
+}
+
+// upstreamComponent clears the fields of a component that upstream
+// discordgo doesn't have.
+func upstreamComponent(component MessageComponent) MessageComponent {
+	switch c := component.(type) {
+	case Button:
+		c.Tooltip = ""
+		c.Badge = nil
+		c.BadgeText = ""
+		c.Loading = false
+		c.Size = ""
+		c.Extra = nil
+		return c
+	case SelectMenu:
+		c.Searchable = false
+		c.Grouped = false
+		c.Extra = nil
+		return c
+	case TextInput:
+		c.ValidationPattern = ""
+		c.Masked = false
+		c.compiled = nil
+		return c
+	}
+	return component
+}
+
+// ===== PREVIEW =====
+
+// Draw a rough text preview of a tree for terminal tools. Each row of
+// buttons and selects becomes one line, e.g.
+//
+//	+----------------+
+//	| [ Yes ] [ No ] |
+//	+----------------+
+func PreviewBox(root MessageComponent) string {
+	var lines []string
+	previewLines(root, &lines)
+	width := 0
+	for _, line := range lines {
+		if n := graphemeCount(line); n > width {
+			width = n
+		}
+	}
+
+	var sb strings.Builder
+	border := "+" + strings.Repeat("-", width+2) + "+\n"
+	sb.WriteString(border)
+	for _, line := range lines {
+		sb.WriteString("| " + line + strings.Repeat(" ", width-graphemeCount(line)) + " |\n")
+	}
+	sb.WriteString(border)
+	return sb.String()
+}
+
+func previewLines(component MessageComponent, lines *[]string) {
+	switch c := deref(component).(type) {
+	case nil:
+		return
+	case ActionsRow, ButtonGroup:
+		var cells []string
+		for _, child := range componentChildren(c) {
+			if cell, ok := previewCell(child.component); ok {
+				cells = append(cells, cell)
+			} else {
+				previewLines(child.component, lines)
+			}
+		}
+		if len(cells) > 0 {
+			*lines = append(*lines, strings.Join(cells, " "))
+		}
+		return
+	case Modal:
+		*lines = append(*lines, c.Title)
+	case TextDisplay:
+		*lines = append(*lines, strings.Split(c.Content, "\n")...)
+	case Separator:
+		*lines = append(*lines, "-----")
+	default:
+		if cell, ok := previewCell(c); ok {
+			*lines = append(*lines, cell)
+		}
+	}
+	for _, child := range componentChildren(component) {
+		previewLines(child.component, lines)
+	}
+}
+
+// previewCell renders the interactive components that PreviewBox puts
+// side by side.
+func previewCell(component MessageComponent) (string, bool) {
+	switch c := deref(component).(type) {
+	case Button:
+		label := c.Label
+		if label == "" && c.Emoji != nil {
+			label = c.Emoji.Name
+		}
+		return "[ " + label + " ]", true
+	case SelectMenu:
+		return "[ " + c.Placeholder + " v ]", true
+	case TextInput:
+		value := c.Value
+		if value == "" {
+			value = c.Placeholder
+		}
+		return c.Label + ": [ " + value + " ]", true
+	}
+	return "", false
+}
+
+// ===== TEMPLATES =====
+
+var (
+	templatesMu sync.RWMutex
+	templates   = make(map[string]func() MessageComponent)
+)
+
+// Register a named component template, replacing any previous one
+func RegisterTemplate(name string, factory func() MessageComponent) {
+	templatesMu.Lock()
+	templates[name] = factory
+	templatesMu.Unlock()
+}
+
+// Build a fresh copy of a registered template
+func Template(name string) (MessageComponent, bool) {
+	templatesMu.RLock()
+	factory, ok := templates[name]
+	templatesMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return Clone(factory()), true
+}
+
 // ===== VALIDATION =====
 
+var (
+	validatorsMu sync.RWMutex
+	validators   []func(MessageComponent) error
+)
+
+// Register a custom validator that ValidateComponent runs after its
+// built-in checks
+func AddValidator(fn func(MessageComponent) error) {
+	validatorsMu.Lock()
+	validators = append(validators, fn)
+	validatorsMu.Unlock()
+}
+
+// Remove all validators registered with AddValidator
+func ResetValidators() {
+	validatorsMu.Lock()
+	validators = nil
+	validatorsMu.Unlock()
+}
+
 func ValidateComponent(component MessageComponent) error {
+	component = deref(component)
+	if err := validateBuiltin(component); err != nil {
+		return err
+	}
+
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	for _, fn := range validators {
+		if err := fn(component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Label limits, counted in user-perceived characters (see graphemeCount)
+const (
+	maxButtonLabelLength = 80
+	maxOptionLabelLength = 100
+	maxModalTitleLength  = 45
+	maxInputLabelLength  = 45
+)
+
+// Discord's limit on the buttons in one ButtonGroup
+const maxGroupButtons = 25
+
+// Text limits counted in runes
+const (
+	maxCustomIDLength          = 100
+	maxOptionDescriptionLength = 100
+	maxLabelDescriptionLength  = 100
+	maxTextDisplayLength       = 1024
+)
+
+// graphemeCount counts user-perceived characters the way Discord does for
+// length limits: an emoji built from several code points (ZWJ sequences,
+// skin tones, flags, keycaps) or a letter with combining marks counts once.
+// It is a small approximation of Unicode extended grapheme clusters, not a
+// full implementation.
+func graphemeCount(s string) int {
+	count := 0
+	forEachGrapheme(s, func(int) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// truncateGraphemes cuts s down to at most max user-perceived characters.
+func truncateGraphemes(s string, max int) string {
+	end := len(s)
+	count := 0
+	forEachGrapheme(s, func(start int) bool {
+		if count == max {
+			end = start
+			return false
+		}
+		count++
+		return true
+	})
+	return s[:end]
+}
+
+// forEachGrapheme calls fn with the byte offset at which each character
+// starts, stopping early when fn returns false.
+func forEachGrapheme(s string, fn func(start int) bool) {
+	joinNext := false
+	pendingRegional := false
+	prev := rune(-1)
+	for i, r := range s {
+		extends := joinNext || graphemeExtender(r) || (prev == '\r' && r == '\n')
+		if isRegionalIndicator(r) {
+			if pendingRegional {
+				extends = true
+				pendingRegional = false
+			} else {
+				pendingRegional = !extends
+			}
+		} else {
+			pendingRegional = false
+		}
+		if !extends && !fn(i) {
+			return
+		}
+		joinNext = r == '\u200d'
+		prev = r
+	}
+}
+
+func graphemeExtender(r rune) bool {
+	switch {
+	case r == '\u200d', // zero width joiner
+		r == '\u20e3',                // combining enclosing keycap
+		r >= 0xfe00 && r <= 0xfe0f,   // variation selectors
+		r >= 0x1f3fb && r <= 0x1f3ff, // emoji skin tone modifiers
+		r >= 0xe0020 && r <= 0xe007f, // tag characters
+		r >= 0xe0100 && r <= 0xe01ef: // variation selectors supplement
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// validateMediaItem checks that media points at a web URL or an uploaded
+// attachment.
+func validateMediaItem(item UnfurledMediaItem) error {
+	if item.URL == "" {
+		return fmt.Errorf("media must have URL")
+	}
+	u, err := url.Parse(item.URL)
+	if err != nil {
+		return fmt.Errorf("media URL %q is invalid: %v", item.URL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "attachment":
+		return nil
+	}
+	return fmt.Errorf("media URL %q must use http(s):// or attachment://", item.URL)
+}
+
+func isSnowflake(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+func validateBuiltin(component MessageComponent) error {
+	if n := utf8.RuneCountInString(componentCustomID(component)); n > maxCustomIDLength {
+		return fmt.Errorf("custom ID is %d characters, maximum is %d", n, maxCustomIDLength)
+	}
 	switch c := component.(type) {
 	case ActionsRow:
 		if len(c.Components) > 5 {
@@ -459,6 +1758,13 @@ func ValidateComponent(component MessageComponent) error {
 			return fmt.Errorf("actions row must have at least 1 component")
 		}
 	case Button:
+		if c.Style == PremiumButton {
+			// premium buttons are rendered from the SKU alone
+			if !isSnowflake(c.SKUID) {
+				return fmt.Errorf("premium button must have a numeric SKU ID, got %q", c.SKUID)
+			}
+			break
+		}
 		if c.Label == "" && c.Emoji == nil {
 			return fmt.Errorf("button must have either label or emoji")
 		}
@@ -468,6 +1774,12 @@ func ValidateComponent(component MessageComponent) error {
 		if c.Style != LinkButton && c.CustomID == "" {
 			return fmt.Errorf("non-link button must have custom ID")
 		}
+		if n := graphemeCount(c.Label); n > maxButtonLabelLength {
+			return fmt.Errorf("button label is %d characters, maximum is %d", n, maxButtonLabelLength)
+		}
+		if c.Badge != nil && c.BadgeText != "" {
+			return fmt.Errorf("button cannot have both a numeric and a text badge")
+		}
 	case SelectMenu:
 		if c.CustomID == "" {
 			return fmt.Errorf("select menu must have custom ID")
@@ -475,6 +1787,39 @@ func ValidateComponent(component MessageComponent) error {
 		if c.MenuType == StringSelectMenu && len(c.Options) == 0 {
 			return fmt.Errorf("string select menu must have options")
 		}
+		if c.MenuType == MentionableSelectMenu && len(c.Options) > 0 {
+			return fmt.Errorf("mentionable select menu cannot have options")
+		}
+		if c.MenuType == MentionableSelectMenu && len(c.ChannelTypes) > 0 {
+			return fmt.Errorf("mentionable select menu cannot have channel types")
+		}
+		for i, option := range c.Options {
+			if n := graphemeCount(option.Label); n > maxOptionLabelLength {
+				return fmt.Errorf("option %d label is %d characters, maximum is %d", i, n, maxOptionLabelLength)
+			}
+			if option.Value == "" {
+				return fmt.Errorf("option %d must have a value", i)
+			}
+		}
+		defaults := len(c.DefaultValues)
+		for _, option := range c.Options {
+			if option.Default {
+				defaults++
+			}
+		}
+		if defaults > 0 {
+			// Discord treats unset bounds as 1
+			min, max := 1, 1
+			if c.MinValues != nil {
+				min = *c.MinValues
+			}
+			if c.MaxValues > 0 {
+				max = c.MaxValues
+			}
+			if defaults < min || defaults > max {
+				return fmt.Errorf("select menu has %d defaults, must be between %d and %d", defaults, min, max)
+			}
+		}
 	case TextInput:
 		if c.CustomID == "" {
 			return fmt.Errorf("text input must have custom ID")
@@ -482,6 +1827,51 @@ func ValidateComponent(component MessageComponent) error {
 		if c.Label == "" {
 			return fmt.Errorf("text input must have label")
 		}
+		if c.Masked && c.Value != "" {
+			return fmt.Errorf("masked text input must not have a prefilled value")
+		}
+	case TextDisplay:
+		if n := utf8.RuneCountInString(c.Content); n > maxTextDisplayLength {
+			return fmt.Errorf("text display content is %d characters, maximum is %d", n, maxTextDisplayLength)
+		}
+	case ButtonGroup:
+		if len(c.Buttons) > maxGroupButtons {
+			return fmt.Errorf("button group can have maximum %d buttons, has %d", maxGroupButtons, len(c.Buttons))
+		}
+	case Section:
+		if len(c.Components) == 0 || len(c.Components) > maxSectionTexts {
+			return fmt.Errorf("section must have 1 to %d text displays, has %d", maxSectionTexts, len(c.Components))
+		}
+		for i, child := range c.Components {
+			if _, ok := deref(child).(TextDisplay); !ok {
+				return fmt.Errorf("section component %d must be a text display, got %T", i, child)
+			}
+		}
+		switch deref(c.Accessory).(type) {
+		case nil, Button, Thumbnail:
+		default:
+			return fmt.Errorf("section accessory must be a button or thumbnail, got %T", c.Accessory)
+		}
+	case Thumbnail:
+		if err := validateMediaItem(c.Media); err != nil {
+			return fmt.Errorf("thumbnail %w", err)
+		}
+	case MediaGallery:
+		if len(c.Items) == 0 {
+			return fmt.Errorf("media gallery must have at least 1 item")
+		}
+		if len(c.Items) > 10 {
+			return fmt.Errorf("media gallery can have maximum 10 items")
+		}
+		for i, item := range c.Items {
+			if err := validateMediaItem(item.Media); err != nil {
+				return fmt.Errorf("media gallery item %d %w", i, err)
+			}
+		}
+	case FileComponent:
+		if err := validateMediaItem(c.File); err != nil {
+			return fmt.Errorf("file %w", err)
+		}
 	case Modal:
 		if c.CustomID == "" {
 			return fmt.Errorf("modal must have custom ID")
@@ -489,59 +1879,1460 @@ func ValidateComponent(component MessageComponent) error {
 		if c.Title == "" {
 			return fmt.Errorf("modal must have title")
 		}
+		if len(c.Components) > 5 {
+			return fmt.Errorf("modal can have maximum 5 rows")
+		}
+		for i, child := range c.Components {
+			switch v := deref(child).(type) {
+			case ActionsRow:
+				if len(v.Components) != 1 {
+					return fmt.Errorf("modal row %d must contain exactly 1 text input", i)
+				}
+				input, ok := deref(v.Components[0]).(TextInput)
+				if !ok {
+					return fmt.Errorf("modal row %d must contain a text input", i)
+				}
+				if err := validatePrefill(input); err != nil {
+					return err
+				}
+			case Label:
+				switch input := deref(v.Component).(type) {
+				case TextInput:
+					if err := validatePrefill(input); err != nil {
+						return err
+					}
+				case SelectMenu:
+				default:
+					return fmt.Errorf("modal label %d must contain a text input or select menu", i)
+				}
+			case TextInput:
+				return fmt.Errorf("modal text input %d must be wrapped in an actions row", i)
+			case nil:
+				return fmt.Errorf("modal child %d is nil", i)
+			default:
+				return fmt.Errorf("modal child %d must be an actions row or label, got type %d", i, v.Type())
+			}
+		}
+	case Label:
+		if c.Text == "" {
+			return fmt.Errorf("label must have text")
+		}
+		if n := graphemeCount(c.Text); n > maxInputLabelLength {
+			return fmt.Errorf("label is %d characters, maximum is %d", n, maxInputLabelLength)
+		}
+		if n := utf8.RuneCountInString(c.Description); n > maxLabelDescriptionLength {
+			return fmt.Errorf("label description is %d characters, maximum is %d", n, maxLabelDescriptionLength)
+		}
+		if c.Component == nil {
+			return fmt.Errorf("label must have a component")
+		}
 	}
 	return nil
 }
 
-// ===== COMPONENT STRUCTS =====
+// validatePrefill checks a modal text input's prefilled value against its
+// validation pattern.
+func validatePrefill(input TextInput) error {
+	if input.Value != "" && !input.Matches(input.Value) {
+		return fmt.Errorf("modal text input %q is prefilled with %q, which fails its validation pattern", input.CustomID, input.Value)
+	}
+	return nil
+}
 
-type unmarshalableMessageComponent struct {
-	MessageComponent
+// Discord caps the total number of components in a message, counting
+// nested ones
+const MaxMessageComponents = 40
+
+func countComponents(components []MessageComponent, match func(MessageComponent) bool) int {
+	count := 0
+	for _, root := range components {
+		walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+			if match(c) {
+				count++
+			}
+			return true
+		})
+	}
+	return count
 }
 
-func (umc *unmarshalableMessageComponent) UnmarshalJSON(src []byte) error {
-	var v struct {
-		Type ComponentType `json:"type"`
+// Check that a message's components, including nested ones, stay within
+// MaxMessageComponents
+func ValidateComponentCount(components []MessageComponent) error {
+	count := countComponents(components, func(MessageComponent) bool { return true })
+	if count > MaxMessageComponents {
+		return fmt.Errorf("message has %d components, maximum is %d", count, MaxMessageComponents)
 	}
-	err := json.Unmarshal(src, &v)
-	if err != nil {
+	return nil
+}
+
+// Discord allows at most this many select menus in one message
+const MaxMessageSelectMenus = 5
+
+// Check that a message doesn't hold more than MaxMessageSelectMenus selects
+func ValidateSelectCount(components []MessageComponent) error {
+	count := countComponents(components, func(c MessageComponent) bool {
+		_, ok := deref(c).(SelectMenu)
+		return ok
+	})
+	if count > MaxMessageSelectMenus {
+		return fmt.Errorf("message has %d select menus, maximum is %d", count, MaxMessageSelectMenus)
+	}
+	return nil
+}
+
+// Discord allows at most this many text displays in one v2 message
+const MaxMessageTextDisplays = 10
+
+// Check that a message doesn't hold more than MaxMessageTextDisplays text
+// displays, counting nested ones
+func ValidateTextDisplayCount(components []MessageComponent) error {
+	count := countComponents(components, func(c MessageComponent) bool {
+		_, ok := deref(c).(TextDisplay)
+		return ok
+	})
+	if count > MaxMessageTextDisplays {
+		return fmt.Errorf("message has %d text displays, maximum is %d", count, MaxMessageTextDisplays)
+	}
+	return nil
+}
+
+// Check the top level of a message against its layout mode. Without the
+// v2 components flag, a message may only hold action rows, so containers,
+// text displays and other v2 components can't sit next to them.
+func ValidateTopLevelMixing(components []MessageComponent, v2 bool) error {
+	if v2 {
+		return nil
+	}
+	for i, c := range components {
+		if _, ok := deref(c).(ActionsRow); !ok {
+			return fmt.Errorf("components[%d]: %T needs the v2 components flag, only action rows are allowed at the top level without it", i, deref(c))
+		}
+	}
+	return nil
+}
+
+// Check that tab and accordion item IDs are unique within their parent.
+// The same ID may appear in different tab sets or accordions.
+func ValidateNavIDs(root MessageComponent) error {
+	var collisions []string
+	check := func(path, kind string, ids []string) {
+		first := make(map[string]int)
+		for i, id := range ids {
+			if j, ok := first[id]; ok {
+				collisions = append(collisions, fmt.Sprintf("%s[%d] and %s[%d] share ID %q",
+					joinPath(path, kind), j, joinPath(path, kind), i, id))
+				continue
+			}
+			first[id] = i
+		}
+	}
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		switch v := deref(c).(type) {
+		case Tabs:
+			ids := make([]string, len(v.TabList))
+			for i, tab := range v.TabList {
+				ids[i] = tab.ID
+			}
+			check(path, "tabs", ids)
+		case Accordion:
+			ids := make([]string, len(v.Items))
+			for i, item := range v.Items {
+				ids[i] = item.ID
+			}
+			check(path, "items", ids)
+		}
+		return true
+	})
+	if len(collisions) > 0 {
+		return fmt.Errorf("duplicate navigation IDs: %s", strings.Join(collisions, "; "))
+	}
+	return nil
+}
+
+// Check everything Discord requires of a modal in one go: the modal itself,
+// the rows wrapping its inputs, what may appear in a modal, and the rules
+// for each input.
+func ValidateModal(m Modal) error {
+	if n := graphemeCount(m.Title); n > maxModalTitleLength {
+		return fmt.Errorf("modal title is %d characters, maximum is %d", n, maxModalTitleLength)
+	}
+	if err := validateTree(m); err != nil {
+		return err
+	}
+	if err := ValidateForSurface(m, SurfaceModal); err != nil {
 		return err
 	}
 
-	switch v.Type {
-	case ActionsRowComponent:
-		umc.MessageComponent = &ActionsRow{}
-	case ButtonComponent:
-		umc.MessageComponent = &Button{}
-	case SelectMenuComponent, ChannelSelectMenuComponent, UserSelectMenuComponent,
+	var err error
+	seen := make(map[string]bool)
+	walkComponents(m, "", nil, func(path string, parent, c MessageComponent) bool {
+		input, ok := deref(c).(TextInput)
+		if !ok {
+			return true
+		}
+		switch {
+		case seen[input.CustomID]:
+			err = fmt.Errorf("duplicate custom ID %q", input.CustomID)
+		case graphemeCount(input.Label) > maxInputLabelLength:
+			err = fmt.Errorf("label is %d characters, maximum is %d", graphemeCount(input.Label), maxInputLabelLength)
+		case input.MaxLength > 0 && input.MinLength > input.MaxLength:
+			err = fmt.Errorf("min length %d exceeds max length %d", input.MinLength, input.MaxLength)
+		case input.MaxLength > 0 && utf8.RuneCountInString(input.Value) > input.MaxLength:
+			err = fmt.Errorf("prefilled value is longer than max length %d", input.MaxLength)
+		}
+		seen[input.CustomID] = true
+		if err != nil {
+			err = fmt.Errorf("%s: %w", path, err)
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// Oldest Discord API version that accepts the component type. Note that
+// the package itself talks to the API version in APIVersion.
+func MinAPIVersion(t ComponentType) int {
+	switch t {
+	case ActionsRowComponent, ButtonComponent, SelectMenuComponent:
+		return 8
+	case TextInputComponent:
+		return 9
+	}
+	return 10
+}
+
+// Check that every component in the tree is supported by the given API
+// version
+func ValidateAPIVersion(root MessageComponent, version int) error {
+	var err error
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if min := MinAPIVersion(c.Type()); min > version {
+			err = fmt.Errorf("component type %d requires API v%d, have v%d", c.Type(), min, version)
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// Symbolic names of the optional v2 features used anywhere in the tree,
+// such as "button.tooltip" or "select.searchable", in the order they are
+// first found. Bots can use them to fall back on clients that lack them.
+func UsedFeatures(root MessageComponent) []string {
+	var features []string
+	seen := make(map[string]bool)
+	use := func(name string, used bool) {
+		if used && !seen[name] {
+			seen[name] = true
+			features = append(features, name)
+		}
+	}
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		switch v := deref(c).(type) {
+		case Button:
+			use("button.tooltip", v.Tooltip != "")
+			use("button.badge", v.Badge != nil || v.BadgeText != "")
+			use("button.loading", v.Loading)
+			use("button.size", v.Size != "")
+		case SelectMenu:
+			use("select.searchable", v.Searchable)
+			use("select.grouped", v.Grouped)
+		case TextInput:
+			use("text_input.validation_pattern", v.ValidationPattern != "")
+			use("text_input.masked", v.Masked)
+		case Modal:
+			use("modal.size", v.Size != "")
+			use("modal.closable", v.Closable)
+		case ButtonGroup:
+			use("button_group.vertical", v.Vertical)
+			use("button_group.wrap", v.Wrap)
+		case Accordion:
+			use("accordion.multiple", v.Multiple)
+		}
+		return true
+	})
+	return features
+}
+
+// Where a component tree is going to be shown
+type Surface int
+
+const (
+	SurfaceMessage Surface = iota
+	SurfaceModal
+	SurfaceInteractionResponse
+)
+
+func (s Surface) String() string {
+	switch s {
+	case SurfaceMessage:
+		return "message"
+	case SurfaceModal:
+		return "modal"
+	case SurfaceInteractionResponse:
+		return "interaction response"
+	}
+	return fmt.Sprintf("surface(%d)", int(s))
+}
+
+// Check the surface-specific rules for every component in the tree. This
+// complements ValidateComponent, which checks each component on its own.
+func ValidateForSurface(root MessageComponent, s Surface) error {
+	var err error
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if err = validateSurfaceComponent(deref(c), parent == nil, s); err != nil {
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+func validateSurfaceComponent(c MessageComponent, isRoot bool, s Surface) error {
+	switch s {
+	case SurfaceModal:
+		switch c.(type) {
+		case ActionsRow, TextInput, SelectMenu, TextDisplay, Label:
+		case Modal:
+			if !isRoot {
+				return fmt.Errorf("modals cannot be nested")
+			}
+		default:
+			return fmt.Errorf("component type %d is not allowed in a %s", c.Type(), s)
+		}
+	case SurfaceMessage, SurfaceInteractionResponse:
+		switch c.(type) {
+		case Modal:
+			return fmt.Errorf("modals cannot be sent in a %s", s)
+		case TextInput:
+			return fmt.Errorf("text inputs are only allowed in modals, not in a %s", s)
+		}
+	}
+	return nil
+}
+
+// Marshal a component and report, for each JSON field declared on its
+// struct, whether the field made it into the output. Meant for tests that
+// feed in fully-populated components to catch silently dropped fields.
+func FieldCoverage(c MessageComponent) map[string]bool {
+	c = deref(c)
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil
+	}
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil
+	}
+
+	coverage := make(map[string]bool)
+	t := reflect.TypeOf(c)
+	if t.Kind() != reflect.Struct {
+		return coverage
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		_, coverage[name] = out[name]
+	}
+	return coverage
+}
+
+type IssueSeverity int
+
+const (
+	IssueError IssueSeverity = iota
+	IssueWarning
+)
+
+// A single problem found by ValidateReport. Path locates the component
+// relative to the validated root, e.g. "components[1].components[0]".
+type ValidationIssue struct {
+	Path     string
+	Severity IssueSeverity
+	Message  string
+}
+
+func (i ValidationIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+// validateTree runs ValidateComponent on every component in the tree and
+// returns the first error, prefixed with its path.
+func validateTree(root MessageComponent) error {
+	var err error
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if err = ValidateComponent(c); err != nil {
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// ReportConfig holds the settings ValidateReport runs with.
+type ReportConfig struct {
+	// Add warnings about layouts that are valid but likely to confuse users
+	Strict bool
+}
+
+// ReportOption is a function which mutates report configuration.
+// It can be supplied as an argument to ValidateReport and Lint.
+type ReportOption func(cfg *ReportConfig)
+
+// WithStrictValidation controls whether the report includes the strict
+// warnings.
+func WithStrictValidation(strict bool) ReportOption {
+	return func(cfg *ReportConfig) {
+		cfg.Strict = strict
+	}
+}
+
+// Validate every component in the tree and collect all issues instead of
+// stopping at the first one
+func ValidateReport(root MessageComponent, options ...ReportOption) []ValidationIssue {
+	var cfg ReportConfig
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	var issues []ValidationIssue
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if err := ValidateComponent(c); err != nil {
+			issues = append(issues, ValidationIssue{Path: path, Severity: IssueError, Message: err.Error()})
+		}
+		for _, msg := range componentWarnings(deref(c), cfg.Strict) {
+			issues = append(issues, ValidationIssue{Path: path, Severity: IssueWarning, Message: msg})
+		}
+		return true
+	})
+	if withID, withoutID := countIDs(root); withID > 0 && withoutID > 0 {
+		issues = append(issues, ValidationIssue{
+			Severity: IssueWarning,
+			Message:  fmt.Sprintf("%d components have IDs and %d don't; set all or none, e.g. with AssignIDs", withID, withoutID),
+		})
+	}
+	return issues
+}
+
+// Count the components in the tree that can carry a numeric ID, split by
+// whether one is set
+func countIDs(root MessageComponent) (withID, withoutID int) {
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if id, ok := componentID(c); ok {
+			if id != 0 {
+				withID++
+			} else {
+				withoutID++
+			}
+		}
+		return true
+	})
+	return withID, withoutID
+}
+
+// componentWarnings returns the non-fatal problems of a single component.
+// The strict ones are only included when strict is set.
+func componentWarnings(component MessageComponent, strict bool) []string {
+	var warnings []string
+	switch c := component.(type) {
+	case SelectMenu:
+		if strict && c.Type() == SelectMenuComponent && c.Placeholder == "" {
+			hasDefault := false
+			for _, option := range c.Options {
+				hasDefault = hasDefault || option.Default
+			}
+			if !hasDefault {
+				warnings = append(warnings, "select menu has neither a placeholder nor a default option")
+			}
+		}
+		if strict {
+			// Custom emojis are easy to mix up in a long list, so each
+			// should point to a single option
+			owners := make(map[string]string)
+			for _, option := range c.Options {
+				if option.Emoji == nil || option.Emoji.ID == "" {
+					continue
+				}
+				if first, ok := owners[option.Emoji.ID]; ok {
+					warnings = append(warnings, fmt.Sprintf("options %q and %q share custom emoji %s", first, option.Value, option.Emoji.ID))
+					continue
+				}
+				owners[option.Emoji.ID] = option.Value
+			}
+		}
+	case ButtonGroup:
+		if strict {
+			links := 0
+			for _, b := range c.Buttons {
+				if b.Style == LinkButton {
+					links++
+				}
+			}
+			if links > 0 && links < len(c.Buttons) {
+				warnings = append(warnings, "button group mixes link and interactive buttons")
+			}
+		}
+	case Container:
+		hasContent := false
+		for _, child := range c.Components {
+			switch v := deref(child).(type) {
+			case Separator:
+			case TextDisplay:
+				hasContent = hasContent || strings.TrimSpace(v.Content) != ""
+			default:
+				hasContent = hasContent || v != nil
+			}
+		}
+		if !hasContent {
+			warnings = append(warnings, "container only holds separators or empty text and will render as blank space")
+		}
+		if c.AccentColor != nil {
+			if l := relativeLuminance(*c.AccentColor); l < 0.02 || l > 0.9 {
+				warnings = append(warnings, fmt.Sprintf("accent color #%06x is too close to black or white to stand out in every theme", *c.AccentColor))
+			}
+		}
+	}
+	return warnings
+}
+
+// relativeLuminance of a 0xRRGGBB color as defined by WCAG, from 0 for
+// black to 1 for white
+func relativeLuminance(color int) float64 {
+	channel := func(shift uint) float64 {
+		v := float64(color>>shift&0xff) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(16) + 0.7152*channel(8) + 0.0722*channel(0)
+}
+
+// Decode a JSON component and report every validation issue in it
+func Lint(b []byte, options ...ReportOption) []ValidationIssue {
+	component, err := MessageComponentFromJSON(b)
+	if err != nil {
+		return []ValidationIssue{{Severity: IssueError, Message: err.Error()}}
+	}
+	return ValidateReport(component, options...)
+}
+
+// Fix common mistakes in a quick prototype: over-long labels are truncated,
+// missing styles get defaults and bare buttons or selects are wrapped in
+// actions rows. Returns the fixed components and a description of each fix.
+func AutoFix(components []MessageComponent) ([]MessageComponent, []string) {
+	var fixes []string
+	fixed := make([]MessageComponent, 0, len(components))
+	var pending []MessageComponent
+
+	flush := func() {
+		if len(pending) > 0 {
+			fixed = append(fixed, ActionsRow{Components: pending})
+			fixes = append(fixes, fmt.Sprintf("wrapped %d bare component(s) in an actions row", len(pending)))
+			pending = nil
+		}
+	}
+
+	for _, root := range components {
+		c := Map(root, func(c MessageComponent) MessageComponent {
+			return autoFixComponent(c, &fixes)
+		})
+		switch v := c.(type) {
+		case Button:
+			if len(pending) == 5 {
+				flush()
+			}
+			pending = append(pending, v)
+		case SelectMenu:
+			flush()
+			pending = append(pending, v)
+			flush()
+		default:
+			flush()
+			fixed = append(fixed, c)
+		}
+	}
+	flush()
+	return fixed, fixes
+}
+
+func autoFixComponent(component MessageComponent, fixes *[]string) MessageComponent {
+	switch c := component.(type) {
+	case Button:
+		if c.Style == 0 {
+			c.Style = PrimaryButton
+			*fixes = append(*fixes, fmt.Sprintf("set default style on button %q", c.Label))
+		}
+		if graphemeCount(c.Label) > maxButtonLabelLength {
+			c.Label = truncateGraphemes(c.Label, maxButtonLabelLength)
+			*fixes = append(*fixes, fmt.Sprintf("truncated label of button %q to %d characters", c.CustomID, maxButtonLabelLength))
+		}
+		return c
+	case SelectMenu:
+		c.Options = append([]SelectMenuOption(nil), c.Options...)
+		for i, option := range c.Options {
+			if graphemeCount(option.Label) > maxOptionLabelLength {
+				c.Options[i].Label = truncateGraphemes(option.Label, maxOptionLabelLength)
+				*fixes = append(*fixes, fmt.Sprintf("truncated label of option %q to %d characters", option.Value, maxOptionLabelLength))
+			}
+		}
+		return c
+	case TextInput:
+		if c.Style == 0 {
+			c.Style = TextInputShort
+			*fixes = append(*fixes, fmt.Sprintf("set default style on text input %q", c.CustomID))
+		}
+		return c
+	}
+	return component
+}
+
+// ===== TREE HELPERS =====
+
+// Decoded components are pointers; deref returns the value form so type
+// switches only have to handle one case.
+func deref(component MessageComponent) MessageComponent {
+	switch c := component.(type) {
+	case *ActionsRow:
+		if c != nil {
+			return *c
+		}
+	case *Button:
+		if c != nil {
+			return *c
+		}
+	case *SelectMenu:
+		if c != nil {
+			return *c
+		}
+	case *TextInput:
+		if c != nil {
+			return *c
+		}
+	case *ButtonGroup:
+		if c != nil {
+			return *c
+		}
+	case *Modal:
+		if c != nil {
+			return *c
+		}
+	case *Tabs:
+		if c != nil {
+			return *c
+		}
+	case *Accordion:
+		if c != nil {
+			return *c
+		}
+	case *Section:
+		if c != nil {
+			return *c
+		}
+	case *TextDisplay:
+		if c != nil {
+			return *c
+		}
+	case *Thumbnail:
+		if c != nil {
+			return *c
+		}
+	case *MediaGallery:
+		if c != nil {
+			return *c
+		}
+	case *FileComponent:
+		if c != nil {
+			return *c
+		}
+	case *Separator:
+		if c != nil {
+			return *c
+		}
+	case *Container:
+		if c != nil {
+			return *c
+		}
+	case *Label:
+		if c != nil {
+			return *c
+		}
+	}
+	return component
+}
+
+type componentChild struct {
+	path      string
+	component MessageComponent
+}
+
+// componentChildren lists the direct children of a component together with
+// their path relative to it.
+func componentChildren(component MessageComponent) []componentChild {
+	var children []componentChild
+	switch c := deref(component).(type) {
+	case ActionsRow:
+		for i, child := range c.Components {
+			children = append(children, componentChild{fmt.Sprintf("components[%d]", i), child})
+		}
+	case Modal:
+		for i, child := range c.Components {
+			children = append(children, componentChild{fmt.Sprintf("components[%d]", i), child})
+		}
+	case Section:
+		for i, child := range c.Components {
+			children = append(children, componentChild{fmt.Sprintf("components[%d]", i), child})
+		}
+		if c.Accessory != nil {
+			children = append(children, componentChild{"accessory", c.Accessory})
+		}
+	case Container:
+		for i, child := range c.Components {
+			children = append(children, componentChild{fmt.Sprintf("components[%d]", i), child})
+		}
+	case ButtonGroup:
+		for i, child := range c.Buttons {
+			children = append(children, componentChild{fmt.Sprintf("buttons[%d]", i), child})
+		}
+	case Tabs:
+		for i, tab := range c.TabList {
+			children = append(children, componentChild{fmt.Sprintf("tabs[%d].content", i), tab.Content})
+		}
+	case Accordion:
+		for i, item := range c.Items {
+			children = append(children, componentChild{fmt.Sprintf("items[%d].content", i), item.Content})
+		}
+	case Label:
+		if c.Component != nil {
+			children = append(children, componentChild{"component", c.Component})
+		}
+	}
+	return children
+}
+
+func joinPath(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+// walkComponents visits every component depth-first, parents before their
+// children. Returning false from fn stops the walk, which is then reported
+// by the return value.
+func walkComponents(c MessageComponent, path string, parent MessageComponent, fn func(path string, parent, c MessageComponent) bool) bool {
+	if c == nil {
+		return true
+	}
+	if !fn(path, parent, c) {
+		return false
+	}
+	for _, child := range componentChildren(c) {
+		if !walkComponents(child.component, joinPath(path, child.path), c, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Visit every component depth-first together with the component holding
+// it; the root is reported with a nil parent. Returning false from fn stops
+// the walk.
+func WalkWithParent(root MessageComponent, fn func(parent, child MessageComponent) bool) {
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		return fn(parent, c)
+	})
+}
+
+// withChildren returns a copy of component with its children replaced, in
+// the order reported by componentChildren.
+func withChildren(component MessageComponent, children []MessageComponent) MessageComponent {
+	switch c := deref(component).(type) {
+	case ActionsRow:
+		c.Components = append([]MessageComponent(nil), children...)
+		return c
+	case Modal:
+		c.Components = append([]MessageComponent(nil), children...)
+		return c
+	case Section:
+		n := len(c.Components)
+		c.Components = append([]MessageComponent(nil), children[:n]...)
+		if c.Accessory != nil {
+			c.Accessory = children[n]
+		}
+		return c
+	case Container:
+		c.Components = append([]MessageComponent(nil), children...)
+		return c
+	case ButtonGroup:
+		buttons := make([]Button, len(c.Buttons))
+		for i, child := range children {
+			if b, ok := deref(child).(Button); ok {
+				buttons[i] = b
+			} else {
+				buttons[i] = c.Buttons[i]
+			}
+		}
+		c.Buttons = buttons
+		return c
+	case Tabs:
+		c.TabList = append([]Tab(nil), c.TabList...)
+		for i, child := range children {
+			c.TabList[i].Content = child
+		}
+		return c
+	case Accordion:
+		c.Items = append([]AccordionItem(nil), c.Items...)
+		for i, child := range children {
+			c.Items[i].Content = child
+		}
+		return c
+	case Label:
+		if c.Component != nil {
+			c.Component = children[0]
+		}
+		return c
+	}
+	return component
+}
+
+// componentID reports the numeric ID of component and whether its type has
+// one at all.
+func componentID(component MessageComponent) (int, bool) {
+	switch c := deref(component).(type) {
+	case ActionsRow:
+		return c.ID, true
+	case Button:
+		return c.ID, true
+	case SelectMenu:
+		return c.ID, true
+	case TextInput:
+		return c.ID, true
+	case ButtonGroup:
+		return c.ID, true
+	case Section:
+		return c.ID, true
+	case TextDisplay:
+		return c.ID, true
+	case Thumbnail:
+		return c.ID, true
+	case MediaGallery:
+		return c.ID, true
+	case FileComponent:
+		return c.ID, true
+	case Separator:
+		return c.ID, true
+	case Container:
+		return c.ID, true
+	case Label:
+		return c.ID, true
+	}
+	return 0, false
+}
+
+// withID returns a copy of component with its numeric ID replaced. Types
+// without one are returned unchanged.
+func withID(component MessageComponent, id int) MessageComponent {
+	switch c := deref(component).(type) {
+	case ActionsRow:
+		c.ID = id
+		return c
+	case Button:
+		c.ID = id
+		return c
+	case SelectMenu:
+		c.ID = id
+		return c
+	case TextInput:
+		c.ID = id
+		return c
+	case ButtonGroup:
+		c.ID = id
+		return c
+	case Section:
+		c.ID = id
+		return c
+	case TextDisplay:
+		c.ID = id
+		return c
+	case Thumbnail:
+		c.ID = id
+		return c
+	case MediaGallery:
+		c.ID = id
+		return c
+	case FileComponent:
+		c.ID = id
+		return c
+	case Separator:
+		c.ID = id
+		return c
+	case Container:
+		c.ID = id
+		return c
+	case Label:
+		c.ID = id
+		return c
+	}
+	return component
+}
+
+// Return a copy of the tree where every component without a numeric ID
+// gets one, numbered depth-first after the highest ID already in use.
+// Existing IDs are kept.
+func AssignIDs(root MessageComponent) MessageComponent {
+	next := 0
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if id, _ := componentID(c); id > next {
+			next = id
+		}
+		return true
+	})
+	return assignIDs(root, &next)
+}
+
+// Return a copy of the tree with offset added to every numeric ID that is
+// set, e.g. to merge two layouts numbered by AssignIDs. Unset IDs stay 0.
+func RemapIDs(root MessageComponent, offset int) MessageComponent {
+	return Map(root, func(c MessageComponent) MessageComponent {
+		if id, _ := componentID(c); id != 0 {
+			return withID(c, id+offset)
+		}
+		return c
+	})
+}
+
+func assignIDs(component MessageComponent, next *int) MessageComponent {
+	if component == nil {
+		return nil
+	}
+	component = deref(component)
+	if id, ok := componentID(component); ok && id == 0 {
+		*next++
+		component = withID(component, *next)
+	}
+	if children := componentChildren(component); len(children) > 0 {
+		assigned := make([]MessageComponent, len(children))
+		for i, child := range children {
+			assigned[i] = assignIDs(child.component, next)
+		}
+		component = withChildren(component, assigned)
+	}
+	return component
+}
+
+// Apply fn bottom-up to every component in the tree and return the rebuilt
+// tree; the input is left untouched. Components are handed to fn in value
+// form, so decoded pointers come back as values.
+func Map(root MessageComponent, fn func(MessageComponent) MessageComponent) MessageComponent {
+	if root == nil {
+		return nil
+	}
+	root = deref(root)
+	if children := componentChildren(root); len(children) > 0 {
+		mapped := make([]MessageComponent, len(children))
+		for i, child := range children {
+			mapped[i] = Map(child.component, fn)
+		}
+		root = withChildren(root, mapped)
+	}
+	return fn(root)
+}
+
+// componentCustomID returns the custom ID of components that have one.
+func componentCustomID(component MessageComponent) string {
+	switch c := deref(component).(type) {
+	case Button:
+		return c.CustomID
+	case SelectMenu:
+		return c.CustomID
+	case TextInput:
+		return c.CustomID
+	case Modal:
+		return c.CustomID
+	case Tabs:
+		return c.CustomID
+	case Accordion:
+		return c.CustomID
+	}
+	return ""
+}
+
+// withCustomID returns a copy of component with its custom ID replaced.
+func withCustomID(component MessageComponent, customID string) MessageComponent {
+	switch c := deref(component).(type) {
+	case Button:
+		c.CustomID = customID
+		return c
+	case SelectMenu:
+		c.CustomID = customID
+		return c
+	case TextInput:
+		c.CustomID = customID
+		return c
+	case Modal:
+		c.CustomID = customID
+		return c
+	case Tabs:
+		c.CustomID = customID
+		return c
+	case Accordion:
+		c.CustomID = customID
+		return c
+	}
+	return component
+}
+
+// Return a deep copy of the tree that shares no slices or pointers with
+// the original
+func Clone(root MessageComponent) MessageComponent {
+	return Map(root, cloneComponent)
+}
+
+// Return a deep copy of the tree to be shared, e.g. as a template. Nothing
+// enforces it, but callers should treat the result as read-only and Clone
+// it before making changes, so that no holder sees another's edits.
+func Freeze(root MessageComponent) MessageComponent {
+	return Clone(root)
+}
+
+// cloneComponent copies the pointer and slice fields of a single component;
+// Map already takes care of the children.
+func cloneComponent(component MessageComponent) MessageComponent {
+	switch c := component.(type) {
+	case Button:
+		c.Emoji = cloneEmoji(c.Emoji)
+		if c.Badge != nil {
+			badge := *c.Badge
+			c.Badge = &badge
+		}
+		c.Extra = cloneExtra(c.Extra)
+		return c
+	case SelectMenu:
+		if c.MinValues != nil {
+			min := *c.MinValues
+			c.MinValues = &min
+		}
+		c.DefaultValues = append([]SelectMenuDefaultValue(nil), c.DefaultValues...)
+		c.ChannelTypes = append([]ChannelType(nil), c.ChannelTypes...)
+		c.Options = append([]SelectMenuOption(nil), c.Options...)
+		for i := range c.Options {
+			c.Options[i].Emoji = cloneEmoji(c.Options[i].Emoji)
+		}
+		c.Extra = cloneExtra(c.Extra)
+		return c
+	case Tabs:
+		for i := range c.TabList {
+			if c.TabList[i].Badge != nil {
+				badge := *c.TabList[i].Badge
+				c.TabList[i].Badge = &badge
+			}
+			c.TabList[i].Icon = cloneEmoji(c.TabList[i].Icon)
+		}
+		return c
+	case MediaGallery:
+		c.Items = append([]MediaGalleryItem(nil), c.Items...)
+		return c
+	case Container:
+		if c.AccentColor != nil {
+			color := *c.AccentColor
+			c.AccentColor = &color
+		}
+		return c
+	}
+	return component
+}
+
+func cloneEmoji(emoji *ComponentEmoji) *ComponentEmoji {
+	if emoji == nil {
+		return nil
+	}
+	e := *emoji
+	return &e
+}
+
+func cloneExtra(extra map[string]json.RawMessage) map[string]json.RawMessage {
+	if extra == nil {
+		return nil
+	}
+	copied := make(map[string]json.RawMessage, len(extra))
+	for key, value := range extra {
+		copied[key] = append(json.RawMessage(nil), value...)
+	}
+	return copied
+}
+
+// Deep-clone the tree, prefixing every custom ID with prefix+":"
+func CloneWithPrefix(root MessageComponent, prefix string) MessageComponent {
+	return Map(root, func(c MessageComponent) MessageComponent {
+		c = cloneComponent(c)
+		if id := componentCustomID(c); id != "" {
+			c = withCustomID(c, prefix+":"+id)
+		}
+		return c
+	})
+}
+
+// Return a copy of the tree in which masked text inputs have no prefilled
+// Value, so it can be sent without leaking them
+func StripMaskedValues(root MessageComponent) MessageComponent {
+	return Map(root, func(c MessageComponent) MessageComponent {
+		if input, ok := c.(TextInput); ok && input.Masked {
+			input.Value = ""
+			return input
+		}
+		return c
+	})
+}
+
+// List every custom ID in the tree depth-first, each only once
+func CustomIDs(root MessageComponent) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if id := componentCustomID(c); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+		return true
+	})
+	return ids
+}
+
+// Emojis of all buttons in the tree, depth-first, e.g. to add them as
+// reactions. Buttons without an emoji are skipped.
+func ButtonEmojis(root MessageComponent) []ComponentEmoji {
+	var emojis []ComponentEmoji
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if b, ok := deref(c).(Button); ok && b.Emoji != nil {
+			emojis = append(emojis, *b.Emoji)
+		}
+		return true
+	})
+	return emojis
+}
+
+// Report whether anything in the tree sends component interactions the bot
+// has to answer: a select menu, a text input, or a button other than a link
+// or premium button.
+func NeedsHandler(root MessageComponent) bool {
+	return !walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		switch v := deref(c).(type) {
+		case Button:
+			return v.Style == LinkButton || v.Style == PremiumButton
+		case SelectMenu, TextInput:
+			return false
+		}
+		return true
+	})
+}
+
+// Pair components of two trees by custom ID and return the IDs whose
+// content differs, including ones added or removed. IDs are ordered as they
+// appear in new, followed by those only found in old.
+func ChangedIDs(old, new MessageComponent) []string {
+	oldByID := componentsByCustomID(old)
+	var changed []string
+	seen := make(map[string]bool)
+	walkComponents(new, "", nil, func(path string, parent, c MessageComponent) bool {
+		id := componentCustomID(c)
+		if id == "" || seen[id] {
+			return true
+		}
+		seen[id] = true
+		before, ok := oldByID[id]
+		if !ok || !componentsEqual(before, c) {
+			changed = append(changed, id)
+		}
+		return true
+	})
+	walkComponents(old, "", nil, func(path string, parent, c MessageComponent) bool {
+		if id := componentCustomID(c); id != "" && !seen[id] {
+			seen[id] = true
+			changed = append(changed, id)
+		}
+		return true
+	})
+	return changed
+}
+
+func componentsByCustomID(root MessageComponent) map[string]MessageComponent {
+	byID := make(map[string]MessageComponent)
+	walkComponents(root, "", nil, func(path string, parent, c MessageComponent) bool {
+		if id := componentCustomID(c); id != "" {
+			if _, ok := byID[id]; !ok {
+				byID[id] = c
+			}
+		}
+		return true
+	})
+	return byID
+}
+
+// componentsEqual compares two components by their JSON form.
+func componentsEqual(a, b MessageComponent) bool {
+	ab, err := json.Marshal(deref(a))
+	if err != nil {
+		return false
+	}
+	bb, err := json.Marshal(deref(b))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ab, bb)
+}
+
+// Replace the first component below root with the given custom ID by the
+// result of patch. The tree is modified in place; root itself is never
+// replaced, so the accessory of a Section or the component of a Label can
+// only be patched when the root is passed as a pointer. Reports whether a
+// component was replaced.
+func Patch(root MessageComponent, customID string, patch func(MessageComponent) MessageComponent) bool {
+	if customID == "" {
+		return false
+	}
+	return patchChildren(root, customID, patch)
+}
+
+func patchSlot(slot *MessageComponent, customID string, patch func(MessageComponent) MessageComponent) bool {
+	if *slot == nil {
+		return false
+	}
+	if componentCustomID(*slot) == customID {
+		*slot = patch(*slot)
+		return true
+	}
+	if patchChildren(*slot, customID, patch) {
+		return true
+	}
+	// A section held by value needs to be copied back into its slot when
+	// its accessory changes
+	if section, ok := (*slot).(Section); ok && patchSlot(&section.Accessory, customID, patch) {
+		*slot = section
+		return true
+	}
+	if label, ok := (*slot).(Label); ok && patchSlot(&label.Component, customID, patch) {
+		*slot = label
+		return true
+	}
+	return false
+}
+
+func patchChildren(component MessageComponent, customID string, patch func(MessageComponent) MessageComponent) bool {
+	var slots []MessageComponent
+	switch c := deref(component).(type) {
+	case ActionsRow:
+		slots = c.Components
+	case Modal:
+		slots = c.Components
+	case Section:
+		slots = c.Components
+		if s, ok := component.(*Section); ok {
+			for i := range slots {
+				if patchSlot(&slots[i], customID, patch) {
+					return true
+				}
+			}
+			return patchSlot(&s.Accessory, customID, patch)
+		}
+	case Container:
+		slots = c.Components
+	case ButtonGroup:
+		for i := range c.Buttons {
+			if c.Buttons[i].CustomID == customID {
+				// a group can only hold buttons, so anything else is
+				// reported as not patched
+				b, ok := deref(patch(c.Buttons[i])).(Button)
+				if ok {
+					c.Buttons[i] = b
+				}
+				return ok
+			}
+		}
+	case Tabs:
+		for i := range c.TabList {
+			if patchSlot(&c.TabList[i].Content, customID, patch) {
+				return true
+			}
+		}
+	case Accordion:
+		for i := range c.Items {
+			if patchSlot(&c.Items[i].Content, customID, patch) {
+				return true
+			}
+		}
+	case Label:
+		if l, ok := component.(*Label); ok {
+			return patchSlot(&l.Component, customID, patch)
+		}
+	}
+	for i := range slots {
+		if patchSlot(&slots[i], customID, patch) {
+			return true
+		}
+	}
+	return false
+}
+
+// Disable every select menu and non-link button in the tree, descending
+// into rows, containers, sections, tabs and accordions
+func Disable(root MessageComponent) MessageComponent {
+	return Map(root, func(c MessageComponent) MessageComponent {
+		switch v := c.(type) {
+		case Button:
+			if v.Style != LinkButton {
+				v.Disabled = true
+			}
+			return v
+		case SelectMenu:
+			v.Disabled = true
+			return v
+		}
+		return c
+	})
+}
+
+// Disable every interactive button except the chosen one, which is
+// re-enabled and keeps its style
+func SelectOne(root MessageComponent, chosenCustomID string) MessageComponent {
+	return selectOne(root, chosenCustomID, 0)
+}
+
+// Like SelectOne, but the chosen button is also restyled, e.g. as a
+// SuccessButton
+func SelectOneStyled(root MessageComponent, chosenCustomID string, style ButtonStyle) MessageComponent {
+	return selectOne(root, chosenCustomID, style)
+}
+
+// selectOne leaves the chosen button's style alone when style is 0.
+func selectOne(root MessageComponent, chosenCustomID string, style ButtonStyle) MessageComponent {
+	return Map(root, func(c MessageComponent) MessageComponent {
+		b, ok := c.(Button)
+		if !ok || b.Style == LinkButton || b.Style == PremiumButton {
+			return c
+		}
+		if b.CustomID == chosenCustomID {
+			b.Disabled = false
+			if style != 0 {
+				b.Style = style
+			}
+		} else {
+			b.Disabled = true
+		}
+		return b
+	})
+}
+
+// ===== COMPONENT STRUCTS =====
+
+type unmarshalableMessageComponent struct {
+	MessageComponent
+}
+
+func (umc *unmarshalableMessageComponent) UnmarshalJSON(src []byte) error {
+	var v struct {
+		Type ComponentType `json:"type"`
+	}
+	err := json.Unmarshal(src, &v)
+	if err != nil {
+		return err
+	}
+
+	umc.MessageComponent = newComponent(v.Type)
+	if umc.MessageComponent == nil {
+		return fmt.Errorf("unknown component type: %d", v.Type)
+	}
+	return json.Unmarshal(src, umc.MessageComponent)
+}
+
+// newComponent returns a pointer to an empty component of the given type,
+// or nil if the type is unknown.
+func newComponent(t ComponentType) MessageComponent {
+	switch t {
+	case ActionsRowComponent:
+		return &ActionsRow{}
+	case ButtonComponent:
+		return &Button{}
+	case SelectMenuComponent, ChannelSelectMenuComponent, UserSelectMenuComponent,
 		RoleSelectMenuComponent, MentionableSelectMenuComponent:
-		umc.MessageComponent = &SelectMenu{}
+		return &SelectMenu{MenuType: SelectMenuType(t)}
 	case TextInputComponent:
-		umc.MessageComponent = &TextInput{}
+		return &TextInput{}
 	case SectionComponent:
-		umc.MessageComponent = &Section{}
+		return &Section{}
 	case TextDisplayComponent:
-		umc.MessageComponent = &TextDisplay{}
+		return &TextDisplay{}
 	case ThumbnailComponent:
-		umc.MessageComponent = &Thumbnail{}
+		return &Thumbnail{}
 	case MediaGalleryComponent:
-		umc.MessageComponent = &MediaGallery{}
+		return &MediaGallery{}
 	case FileComponentType:
-		umc.MessageComponent = &FileComponent{}
+		return &FileComponent{}
 	case SeparatorComponent:
-		umc.MessageComponent = &Separator{}
+		return &Separator{}
 	case ContainerComponent:
-		umc.MessageComponent = &Container{}
+		return &Container{}
+	case ButtonGroupComponent:
+		return &ButtonGroup{}
 	case ModalComponent:
-		umc.MessageComponent = &Modal{}
+		return &Modal{}
 	case TabsComponent:
-		umc.MessageComponent = &Tabs{}
+		return &Tabs{}
 	case AccordionComponent:
-		umc.MessageComponent = &Accordion{}
-	default:
-		return fmt.Errorf("unknown component type: %d", v.Type)
+		return &Accordion{}
+	case LabelComponent:
+		return &Label{}
+	}
+	return nil
+}
+
+// Marshaled size in bytes of an empty component of the given type, useful
+// for pre-sizing buffers. Unknown types report 0.
+func EstimatedMinSize(t ComponentType) int {
+	minSizesOnce.Do(computeMinSizes)
+	return minSizes[t]
+}
+
+var (
+	minSizesOnce sync.Once
+	minSizes     map[ComponentType]int
+)
+
+// computeMinSizes marshals an empty component of every known type once.
+func computeMinSizes() {
+	minSizes = make(map[ComponentType]int)
+	for t := ActionsRowComponent; t <= LabelComponent; t++ {
+		c := newComponent(t)
+		if c == nil {
+			continue
+		}
+		if b, err := json.Marshal(deref(c)); err == nil {
+			minSizes[t] = len(b)
+		}
 	}
-	return json.Unmarshal(src, umc.MessageComponent)
 }
 
 func MessageComponentFromJSON(b []byte) (MessageComponent, error) {
@@ -553,6 +3344,155 @@ func MessageComponentFromJSON(b []byte) (MessageComponent, error) {
 	return u.MessageComponent, nil
 }
 
+// JSON keys modeled by Button and SelectMenu, including the type key
+var (
+	buttonFields     = jsonFieldNames(reflect.TypeOf(Button{}))
+	selectMenuFields = jsonFieldNames(reflect.TypeOf(SelectMenu{}))
+)
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{"type": true}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// unknownFields returns the keys of the JSON object in data that are not
+// in known, or nil if there are none.
+func unknownFields(data []byte, known map[string]bool) (map[string]json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	var extra map[string]json.RawMessage
+	for key, value := range all {
+		if known[key] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+	return extra, nil
+}
+
+// appendExtra adds the extra fields, sorted by key, to the end of the
+// marshaled object b. Keys that are modeled are skipped so Extra can't
+// override them.
+func appendExtra(b []byte, extra map[string]json.RawMessage, known map[string]bool) ([]byte, error) {
+	if len(extra) == 0 {
+		return b, nil
+	}
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(extra[key]) {
+			return nil, fmt.Errorf("extra field %s is not valid JSON", name)
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(extra[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Decode the components array of a message object returned by the API
+func ParseMessageComponents(raw json.RawMessage) ([]MessageComponent, error) {
+	var v []unmarshalableMessageComponent
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message components: %w", err)
+	}
+	components := make([]MessageComponent, len(v))
+	for i, c := range v {
+		components[i] = c.MessageComponent
+	}
+	return components, nil
+}
+
+// Read the custom IDs out of a JSON component payload token by token,
+// without decoding the components. IDs are deduplicated and kept in the
+// order they appear, like CustomIDs.
+func StreamCustomIDs(r io.Reader) ([]string, error) {
+	type frame struct {
+		object    bool
+		expectKey bool
+	}
+	var (
+		ids     []string
+		stack   []frame
+		isID    bool
+		seen    = make(map[string]bool)
+		decoder = json.NewDecoder(r)
+	)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF && len(stack) == 0 {
+			return ids, nil
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read component payload: %w", err)
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+		if str, ok := tok.(string); ok && top != nil && top.object && top.expectKey {
+			top.expectKey = false
+			isID = str == "custom_id"
+			continue
+		}
+
+		// Anything else is a value, or the end of one
+		if str, ok := tok.(string); ok && isID && !seen[str] {
+			seen[str] = true
+			ids = append(ids, str)
+		}
+		isID = false
+		switch tok {
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if top != nil && top.object {
+			top.expectKey = true
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, frame{object: true, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, frame{})
+		}
+	}
+}
+
 // Container for other components
 type ActionsRow struct {
 	Components []MessageComponent `json:"components"`
@@ -570,6 +3510,141 @@ func (r ActionsRow) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// MarshalJSONFast produces the same bytes as MarshalJSON without going
+// through reflection when the row holds only plain buttons, i.e. without
+// emoji, badge or extra fields. Any other row falls back to MarshalJSON.
+func (r ActionsRow) MarshalJSONFast() ([]byte, error) {
+	if r.Components == nil {
+		return r.MarshalJSON()
+	}
+	buttons := make([]Button, len(r.Components))
+	for i, c := range r.Components {
+		b, ok := deref(c).(Button)
+		if !ok || b.Emoji != nil || b.Badge != nil || b.Extra != nil {
+			return r.MarshalJSON()
+		}
+		buttons[i] = b
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(EstimatedMinSize(ActionsRowComponent) + len(buttons)*EstimatedMinSize(ButtonComponent))
+	buf.WriteString(`{"components":[`)
+	for i, b := range buttons {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if !writeFastButton(&buf, b) {
+			return r.MarshalJSON()
+		}
+	}
+	buf.WriteByte(']')
+	if r.ID != 0 {
+		buf.WriteString(`,"id":`)
+		buf.WriteString(strconv.Itoa(r.ID))
+	}
+	buf.WriteString(`,"type":`)
+	buf.WriteString(strconv.Itoa(int(r.Type())))
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeFastButton writes b in the field order of Button.MarshalJSON. It
+// reports false if a string needs an escape that is only sure to match
+// encoding/json when left to it.
+func writeFastButton(buf *bytes.Buffer, b Button) bool {
+	if b.Style == 0 {
+		b.Style = PrimaryButton
+	}
+	ok := true
+	str := func(key, value string, omitEmpty bool) {
+		if omitEmpty && value == "" {
+			return
+		}
+		buf.WriteString(`,"` + key + `":`)
+		ok = ok && writeFastString(buf, value)
+	}
+
+	buf.WriteString(`{"label":`)
+	ok = writeFastString(buf, b.Label)
+	buf.WriteString(`,"style":`)
+	buf.WriteString(strconv.Itoa(int(b.Style)))
+	buf.WriteString(`,"disabled":`)
+	buf.WriteString(strconv.FormatBool(b.Disabled))
+	str("url", b.URL, true)
+	str("custom_id", b.CustomID, true)
+	str("sku_id", b.SKUID, true)
+	if b.ID != 0 {
+		buf.WriteString(`,"id":`)
+		buf.WriteString(strconv.Itoa(b.ID))
+	}
+	str("tooltip", b.Tooltip, true)
+	str("badge_text", b.BadgeText, true)
+	if b.Loading {
+		buf.WriteString(`,"loading":true`)
+	}
+	str("size", string(b.Size), true)
+	buf.WriteString(`,"type":`)
+	buf.WriteString(strconv.Itoa(int(b.Type())))
+	buf.WriteByte('}')
+	return ok
+}
+
+// writeFastString writes s as a JSON string escaped like json.Marshal does,
+// including its HTML escaping. Control characters other than \n, \r and \t
+// are refused, since their escapes differ between Go versions.
+func writeFastString(buf *bytes.Buffer, s string) bool {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			var esc string
+			switch c {
+			case '"', '\\':
+				esc = `\` + string(c)
+			case '\n':
+				esc = `\n`
+			case '\r':
+				esc = `\r`
+			case '\t':
+				esc = `\t`
+			case '<', '>', '&':
+				esc = `\u00` + string(hex[c>>4]) + string(hex[c&0xf])
+			default:
+				if c < 0x20 {
+					return false
+				}
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			buf.WriteString(esc)
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202` + string(hex[r&0xf]))
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+	return true
+}
+
 func (r *ActionsRow) UnmarshalJSON(data []byte) error {
 	type actionsRow ActionsRow
 	var v struct {
@@ -632,10 +3707,17 @@ type Button struct {
 	ID       int             `json:"id,omitempty"`
 	
 	// v2 additions
-	Tooltip string      `json:"tooltip,omitempty"`
-	Badge   *int        `json:"badge,omitempty"`
-	Loading bool        `json:"loading,omitempty"`
-	Size    ButtonSize  `json:"size,omitempty"`
+	Tooltip   string     `json:"tooltip,omitempty"`
+	Badge     *int       `json:"badge,omitempty"`
+	BadgeText string     `json:"badge_text,omitempty"`
+	Loading   bool       `json:"loading,omitempty"`
+	Size      ButtonSize `json:"size,omitempty"`
+
+	// Fields this package doesn't know yet, kept from decoding so they
+	// survive being marshaled again
+	Extra map[string]json.RawMessage `json:"-"`
+
+	sortKey string
 }
 
 func (b Button) MarshalJSON() ([]byte, error) {
@@ -643,25 +3725,53 @@ func (b Button) MarshalJSON() ([]byte, error) {
 	if b.Style == 0 {
 		b.Style = PrimaryButton
 	}
-	return json.Marshal(struct {
+	data, err := json.Marshal(struct {
 		button
 		Type ComponentType `json:"type"`
 	}{
 		button: button(b),
 		Type:   b.Type(),
 	})
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(data, b.Extra, buttonFields)
+}
+
+func (b *Button) UnmarshalJSON(data []byte) error {
+	type button Button
+	if err := json.Unmarshal(data, (*button)(b)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, buttonFields)
+	b.Extra = extra
+	return err
 }
 
 func (Button) Type() ComponentType {
 	return ButtonComponent
 }
 
+// SortKey returns the key set with ButtonBuilder.SortKey
+func (b Button) SortKey() string {
+	return b.sortKey
+}
+
+// Return a copy of buttons stably sorted by the given key, e.g. Button.SortKey
+func SortButtons(buttons []Button, by func(Button) string) []Button {
+	sorted := append([]Button(nil), buttons...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return by(sorted[i]) < by(sorted[j])
+	})
+	return sorted
+}
+
 type SelectMenuOption struct {
 	Label       string          `json:"label,omitempty"`
 	Value       string          `json:"value"`
 	Description string          `json:"description"`
 	Emoji       *ComponentEmoji `json:"emoji,omitempty"`
-	Default     bool            `json:"default"`
+	Default     bool            `json:"default,omitempty"`
 }
 
 type SelectMenuDefaultValueType string
@@ -702,6 +3812,9 @@ type SelectMenu struct {
 	// v2 additions
 	Searchable bool `json:"searchable,omitempty"`
 	Grouped    bool `json:"grouped,omitempty"`
+
+	// Fields this package doesn't know yet, see Button.Extra
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 func (s SelectMenu) Type() ComponentType {
@@ -713,13 +3826,27 @@ func (s SelectMenu) Type() ComponentType {
 
 func (s SelectMenu) MarshalJSON() ([]byte, error) {
 	type selectMenu SelectMenu
-	return json.Marshal(struct {
+	b, err := json.Marshal(struct {
 		selectMenu
 		Type ComponentType `json:"type"`
 	}{
 		selectMenu: selectMenu(s),
 		Type:       s.Type(),
 	})
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(b, s.Extra, selectMenuFields)
+}
+
+func (s *SelectMenu) UnmarshalJSON(data []byte) error {
+	type selectMenu SelectMenu
+	if err := json.Unmarshal(data, (*selectMenu)(s)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, selectMenuFields)
+	s.Extra = extra
+	return err
 }
 
 type TextInputStyle uint
@@ -743,6 +3870,40 @@ type TextInput struct {
 	// v2 additions
 	ValidationPattern string `json:"validation_pattern,omitempty"`
 	Masked           bool   `json:"masked,omitempty"`
+
+	// shared between copies so the pattern is only compiled once
+	compiled *compiledPattern
+}
+
+type compiledPattern struct {
+	once   sync.Once
+	source string
+	re     *regexp.Regexp
+	err    error
+}
+
+func (p *compiledPattern) regexp() (*regexp.Regexp, error) {
+	p.once.Do(func() {
+		p.re, p.err = regexp.Compile("^(?:" + p.source + ")$")
+	})
+	return p.re, p.err
+}
+
+// Matches reports whether value satisfies the whole ValidationPattern. An
+// empty pattern matches everything and an invalid one matches nothing.
+func (m TextInput) Matches(value string) bool {
+	if m.ValidationPattern == "" {
+		return true
+	}
+	p := m.compiled
+	if p == nil || p.source != m.ValidationPattern {
+		p = &compiledPattern{source: m.ValidationPattern}
+	}
+	re, err := p.regexp()
+	if err != nil {
+		return false
+	}
+	return re.MatchString(value)
 }
 
 func (TextInput) Type() ComponentType {
@@ -791,6 +3952,84 @@ func (m Modal) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (m *Modal) UnmarshalJSON(data []byte) error {
+	type modal Modal
+	var v struct {
+		modal
+		RawComponents []unmarshalableMessageComponent `json:"components"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*m = Modal(v.modal)
+
+	if v.RawComponents != nil {
+		m.Components = make([]MessageComponent, len(v.RawComponents))
+		for i, rc := range v.RawComponents {
+			m.Components[i] = rc.MessageComponent
+		}
+	}
+	return nil
+}
+
+// Return a copy of submitted modal values, keyed by custom ID, with the
+// values of masked inputs replaced by "***" so they are safe to log
+func RedactMasked(m Modal, values map[string]string) map[string]string {
+	redacted := make(map[string]string, len(values))
+	for id, value := range values {
+		redacted[id] = value
+	}
+	walkComponents(m, "", nil, func(path string, parent, c MessageComponent) bool {
+		if input, ok := deref(c).(TextInput); ok && input.Masked {
+			if _, submitted := redacted[input.CustomID]; submitted {
+				redacted[input.CustomID] = "***"
+			}
+		}
+		return true
+	})
+	return redacted
+}
+
+// Buttons laid out together without the five-per-row cap of an ActionsRow
+type ButtonGroup struct {
+	Buttons  []Button `json:"buttons"`
+	Vertical bool     `json:"vertical,omitempty"`
+	Wrap     bool     `json:"wrap,omitempty"`
+	ID       int      `json:"id,omitempty"`
+}
+
+func (ButtonGroup) Type() ComponentType { return ButtonGroupComponent }
+
+func (g ButtonGroup) MarshalJSON() ([]byte, error) {
+	type buttonGroup ButtonGroup
+	return json.Marshal(struct {
+		buttonGroup
+		Type ComponentType `json:"type"`
+	}{
+		buttonGroup: buttonGroup(g),
+		Type:        g.Type(),
+	})
+}
+
+// Collect legacy buttons into a single group
+func ButtonsToGroup(buttons ...Button) ButtonGroup {
+	return ButtonGroup{Buttons: append([]Button(nil), buttons...)}
+}
+
+// Split the group back into action rows of at most 5 buttons
+func (g ButtonGroup) ToRows() []ActionsRow {
+	rows := make([]ActionsRow, 0, (len(g.Buttons)+4)/5)
+	for start := 0; start < len(g.Buttons); start += 5 {
+		end := start + 5
+		if end > len(g.Buttons) {
+			end = len(g.Buttons)
+		}
+		rows = append(rows, QuickButtons(g.Buttons[start:end]...))
+	}
+	return rows
+}
+
 type Tab struct {
 	ID      string           `json:"id"`
 	Label   string           `json:"label"`
@@ -799,6 +4038,24 @@ type Tab struct {
 	Icon    *ComponentEmoji  `json:"icon,omitempty"`
 }
 
+func (t *Tab) UnmarshalJSON(data []byte) error {
+	type tab Tab
+	var v struct {
+		tab
+		RawContent *unmarshalableMessageComponent `json:"content"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*t = Tab(v.tab)
+
+	if v.RawContent != nil {
+		t.Content = v.RawContent.MessageComponent
+	}
+	return nil
+}
+
 type Tabs struct {
 	CustomID   string `json:"custom_id"`
 	TabList    []Tab  `json:"tabs"`
@@ -825,6 +4082,24 @@ type AccordionItem struct {
 	Open    bool             `json:"open,omitempty"`
 }
 
+func (item *AccordionItem) UnmarshalJSON(data []byte) error {
+	type accordionItem AccordionItem
+	var v struct {
+		accordionItem
+		RawContent *unmarshalableMessageComponent `json:"content"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*item = AccordionItem(v.accordionItem)
+
+	if v.RawContent != nil {
+		item.Content = v.RawContent.MessageComponent
+	}
+	return nil
+}
+
 type Accordion struct {
 	CustomID string          `json:"custom_id"`
 	Items    []AccordionItem `json:"items"`
@@ -844,63 +4119,243 @@ func (a Accordion) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// ===== PLACEHOLDER TYPES =====
-
-type ChannelType int
-type Section struct{}
-type TextDisplay struct{}
-type Thumbnail struct{}
-type MediaGallery struct{}
-type FileComponent struct{}
-type Separator struct{}
-type Container struct{}
+type Section struct {
+	Components []MessageComponent `json:"components,omitempty"`
+	Accessory  MessageComponent   `json:"accessory,omitempty"`
+	ID         int                `json:"id,omitempty"`
+}
 
-func (Section) Type() ComponentType       { return SectionComponent }
-func (TextDisplay) Type() ComponentType   { return TextDisplayComponent }
-func (Thumbnail) Type() ComponentType     { return ThumbnailComponent }
-func (MediaGallery) Type() ComponentType  { return MediaGalleryComponent }
-func (FileComponent) Type() ComponentType { return FileComponentType }
-func (Separator) Type() ComponentType     { return SeparatorComponent }
-func (Container) Type() ComponentType     { return ContainerComponent }
+func (Section) Type() ComponentType { return SectionComponent }
 
 func (s Section) MarshalJSON() ([]byte, error) {
+	type section Section
 	return json.Marshal(struct {
+		section
 		Type ComponentType `json:"type"`
-	}{Type: s.Type()})
+	}{
+		section: section(s),
+		Type:    s.Type(),
+	})
+}
+
+func (s *Section) UnmarshalJSON(data []byte) error {
+	type section Section
+	var v struct {
+		section
+		RawComponents []unmarshalableMessageComponent `json:"components"`
+		RawAccessory  *unmarshalableMessageComponent  `json:"accessory"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*s = Section(v.section)
+
+	if v.RawAccessory != nil {
+		s.Accessory = v.RawAccessory.MessageComponent
+	}
+
+	if v.RawComponents != nil {
+		s.Components = make([]MessageComponent, len(v.RawComponents))
+		for i, c := range v.RawComponents {
+			s.Components[i] = c.MessageComponent
+		}
+	}
+	return nil
 }
 
+// Markdown text rendered inside v2 layouts
+type TextDisplay struct {
+	Content string `json:"content"`
+	ID      int    `json:"id,omitempty"`
+}
+
+func (TextDisplay) Type() ComponentType { return TextDisplayComponent }
+
 func (td TextDisplay) MarshalJSON() ([]byte, error) {
+	type textDisplay TextDisplay
 	return json.Marshal(struct {
+		textDisplay
 		Type ComponentType `json:"type"`
-	}{Type: td.Type()})
+	}{
+		textDisplay: textDisplay(td),
+		Type:        td.Type(),
+	})
+}
+
+// Media referenced by URL, either external or an attachment://<filename>
+type UnfurledMediaItem struct {
+	URL string `json:"url"`
+}
+
+// Small image shown as a section accessory
+type Thumbnail struct {
+	Media       UnfurledMediaItem `json:"media"`
+	Description string            `json:"description,omitempty"`
+	Spoiler     bool              `json:"spoiler,omitempty"`
+	ID          int               `json:"id,omitempty"`
 }
 
+func (Thumbnail) Type() ComponentType { return ThumbnailComponent }
+
 func (t Thumbnail) MarshalJSON() ([]byte, error) {
+	type thumbnail Thumbnail
 	return json.Marshal(struct {
+		thumbnail
 		Type ComponentType `json:"type"`
-	}{Type: t.Type()})
+	}{
+		thumbnail: thumbnail(t),
+		Type:      t.Type(),
+	})
+}
+
+type MediaGalleryItem struct {
+	Media       UnfurledMediaItem `json:"media"`
+	Description string            `json:"description,omitempty"`
+	Spoiler     bool              `json:"spoiler,omitempty"`
+}
+
+type MediaGallery struct {
+	Items []MediaGalleryItem `json:"items"`
+	ID    int                `json:"id,omitempty"`
 }
 
+func (MediaGallery) Type() ComponentType { return MediaGalleryComponent }
+
 func (mg MediaGallery) MarshalJSON() ([]byte, error) {
+	type mediaGallery MediaGallery
 	return json.Marshal(struct {
+		mediaGallery
 		Type ComponentType `json:"type"`
-	}{Type: mg.Type()})
+	}{
+		mediaGallery: mediaGallery(mg),
+		Type:         mg.Type(),
+	})
+}
+
+// An uploaded file, referenced as attachment://<filename>
+type FileComponent struct {
+	File    UnfurledMediaItem `json:"file"`
+	Spoiler bool              `json:"spoiler,omitempty"`
+	ID      int               `json:"id,omitempty"`
 }
 
+func (FileComponent) Type() ComponentType { return FileComponentType }
+
 func (fc FileComponent) MarshalJSON() ([]byte, error) {
+	type fileComponent FileComponent
 	return json.Marshal(struct {
+		fileComponent
 		Type ComponentType `json:"type"`
-	}{Type: fc.Type()})
+	}{
+		fileComponent: fileComponent(fc),
+		Type:          fc.Type(),
+	})
+}
+
+type SeparatorSpacing int
+
+const (
+	SeparatorSpacingSmall SeparatorSpacing = 1
+	SeparatorSpacingLarge SeparatorSpacing = 2
+)
+
+// Vertical space between components, optionally drawn as a line. Divider is
+// always sent because Discord draws the line when it's missing.
+type Separator struct {
+	Divider bool             `json:"divider"`
+	Spacing SeparatorSpacing `json:"spacing,omitempty"`
+	ID      int              `json:"id,omitempty"`
 }
 
+func (Separator) Type() ComponentType { return SeparatorComponent }
+
 func (s Separator) MarshalJSON() ([]byte, error) {
+	type separator Separator
 	return json.Marshal(struct {
+		separator
 		Type ComponentType `json:"type"`
-	}{Type: s.Type()})
+	}{
+		separator: separator(s),
+		Type:      s.Type(),
+	})
+}
+
+type Container struct {
+	Components  []MessageComponent `json:"components,omitempty"`
+	AccentColor *int               `json:"accent_color,omitempty"` // 0xRRGGBB
+	ID          int                `json:"id,omitempty"`
 }
 
+func (Container) Type() ComponentType { return ContainerComponent }
+
 func (c Container) MarshalJSON() ([]byte, error) {
+	type container Container
+	return json.Marshal(struct {
+		container
+		Type ComponentType `json:"type"`
+	}{
+		container: container(c),
+		Type:      c.Type(),
+	})
+}
+
+func (c *Container) UnmarshalJSON(data []byte) error {
+	type container Container
+	var v struct {
+		container
+		RawComponents []unmarshalableMessageComponent `json:"components"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*c = Container(v.container)
+
+	if v.RawComponents != nil {
+		c.Components = make([]MessageComponent, len(v.RawComponents))
+		for i, rc := range v.RawComponents {
+			c.Components[i] = rc.MessageComponent
+		}
+	}
+	return nil
+}
+
+// Wraps a single input with a label and an optional description
+type Label struct {
+	Text        string           `json:"label"`
+	Description string           `json:"description,omitempty"`
+	Component   MessageComponent `json:"component"`
+	ID          int              `json:"id,omitempty"`
+}
+
+func (Label) Type() ComponentType { return LabelComponent }
+
+func (l Label) MarshalJSON() ([]byte, error) {
+	type label Label
 	return json.Marshal(struct {
+		label
 		Type ComponentType `json:"type"`
-	}{Type: c.Type()})
+	}{
+		label: label(l),
+		Type:  l.Type(),
+	})
+}
+
+func (l *Label) UnmarshalJSON(data []byte) error {
+	type label Label
+	var v struct {
+		label
+		RawComponent *unmarshalableMessageComponent `json:"component"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*l = Label(v.label)
+
+	if v.RawComponent != nil {
+		l.Component = v.RawComponent.MessageComponent
+	}
+	return nil
 }
@@ -0,0 +1,2492 @@
+package discordgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestButtonsToGroup(t *testing.T) {
+	buttons := make([]Button, 6)
+	for i := range buttons {
+		buttons[i] = QuickButton("Button", "btn_"+string(rune('a'+i)), PrimaryButton)
+	}
+
+	group := ButtonsToGroup(buttons...)
+	if len(group.Buttons) != 6 {
+		t.Fatalf("len(group.Buttons) = %d, want 6", len(group.Buttons))
+	}
+
+	rows := group.ToRows()
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	if len(rows[0].Components) != 5 || len(rows[1].Components) != 1 {
+		t.Errorf("row sizes = %d, %d, want 5, 1", len(rows[0].Components), len(rows[1].Components))
+	}
+	if b := rows[1].Components[0].(Button); b.CustomID != "btn_f" {
+		t.Errorf("last button custom ID = %q, want %q", b.CustomID, "btn_f")
+	}
+}
+
+func TestSortButtons(t *testing.T) {
+	cb := NewBuilder()
+	buttons := []Button{
+		cb.Button("Charlie").CustomID("c").SortKey("3").Build(),
+		cb.Button("Alpha").CustomID("a").SortKey("1").Build(),
+		cb.Button("Bravo").CustomID("b").SortKey("2").Build(),
+	}
+
+	byLabel := SortButtons(buttons, func(b Button) string { return b.Label })
+	for i, want := range []string{"Alpha", "Bravo", "Charlie"} {
+		if byLabel[i].Label != want {
+			t.Errorf("byLabel[%d].Label = %q, want %q", i, byLabel[i].Label, want)
+		}
+	}
+	if buttons[0].Label != "Charlie" {
+		t.Error("SortButtons modified its input")
+	}
+
+	byKey := SortButtons(buttons, Button.SortKey)
+	if byKey[0].CustomID != "a" || byKey[2].CustomID != "c" {
+		t.Errorf("unexpected order by sort key: %q, %q, %q", byKey[0].CustomID, byKey[1].CustomID, byKey[2].CustomID)
+	}
+}
+
+func TestSelectOne(t *testing.T) {
+	row := QuickButtons(
+		QuickButton("Red", "poll_red", PrimaryButton),
+		QuickButton("Green", "poll_green", PrimaryButton),
+		QuickButton("Blue", "poll_blue", PrimaryButton),
+	)
+
+	selected, ok := SelectOne(row, "poll_green").(ActionsRow)
+	if !ok {
+		t.Fatalf("SelectOne returned %T, want ActionsRow", selected)
+	}
+	for _, c := range selected.Components {
+		b := c.(Button)
+		if b.CustomID == "poll_green" {
+			if b.Disabled || b.Style != PrimaryButton {
+				t.Errorf("chosen button: disabled = %v, style = %d", b.Disabled, b.Style)
+			}
+		} else if !b.Disabled {
+			t.Errorf("button %q is not disabled", b.CustomID)
+		}
+	}
+	if row.Components[0].(Button).Disabled {
+		t.Error("SelectOne modified its input")
+	}
+
+	styled := SelectOneStyled(row, "poll_green", SuccessButton).(ActionsRow)
+	if b := styled.Components[1].(Button); b.Disabled || b.Style != SuccessButton {
+		t.Errorf("styled chosen button: disabled = %v, style = %d", b.Disabled, b.Style)
+	}
+}
+
+func TestQuickWithID(t *testing.T) {
+	button := QuickButtonWithID("Next", "next", PrimaryButton, 3)
+	if button.ID != 3 {
+		t.Errorf("button.ID = %d, want 3", button.ID)
+	}
+	if button.Label != "Next" || button.CustomID != "next" || button.Style != PrimaryButton {
+		t.Errorf("unexpected button: %+v", button)
+	}
+
+	menu := QuickSelectMenuWithID("color", "Pick a color", 4, QuickOption("Red", "red", ""))
+	if menu.ID != 4 || len(menu.Options) != 1 {
+		t.Errorf("menu.ID = %d, len(menu.Options) = %d, want 4, 1", menu.ID, len(menu.Options))
+	}
+}
+
+func TestAddValidator(t *testing.T) {
+	defer ResetValidators()
+
+	AddValidator(func(c MessageComponent) error {
+		if b, ok := c.(Button); ok && b.Label == "bad" {
+			return fmt.Errorf("label %q is not allowed", b.Label)
+		}
+		return nil
+	})
+
+	if err := ValidateComponent(QuickButton("good", "ok", PrimaryButton)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateComponent(QuickButton("bad", "nope", PrimaryButton)); err == nil {
+		t.Error("expected custom validator to reject label \"bad\"")
+	}
+
+	ResetValidators()
+	if err := ValidateComponent(QuickButton("bad", "nope", PrimaryButton)); err != nil {
+		t.Errorf("unexpected error after ResetValidators: %v", err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	issues := Lint([]byte(`{"type":1,"components":[{"type":2,"label":"Go","style":1}]}`))
+	if len(issues) != 1 {
+		t.Fatalf("len(issues) = %d, want 1: %v", len(issues), issues)
+	}
+	if issues[0].Path != "components[0]" || !strings.Contains(issues[0].Message, "custom ID") {
+		t.Errorf("unexpected issue: %v", issues[0])
+	}
+
+	if issues := Lint([]byte(`{"type":999}`)); len(issues) != 1 || issues[0].Severity != IssueError {
+		t.Errorf("expected a single decode issue, got %v", issues)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	container := Container{
+		Components: []MessageComponent{
+			Section{Components: []MessageComponent{
+				QuickSelectMenu("color", "Pick a color", QuickOption("Red", "red", "")),
+			}},
+			QuickButtons(
+				QuickButton("Go", "go", PrimaryButton),
+				NewBuilder().Button("Docs").Link("https://example.com").Build(),
+			),
+		},
+	}
+
+	disabled := Disable(container).(Container)
+	menu := disabled.Components[0].(Section).Components[0].(SelectMenu)
+	if !menu.Disabled {
+		t.Error("select menu inside section was not disabled")
+	}
+	row := disabled.Components[1].(ActionsRow)
+	if !row.Components[0].(Button).Disabled {
+		t.Error("interactive button was not disabled")
+	}
+	if row.Components[1].(Button).Disabled {
+		t.Error("link button should not be disabled")
+	}
+}
+
+func TestQuickWizard(t *testing.T) {
+	steps := map[string]MessageComponent{
+		"account": QuickButtons(QuickButton("Create account", "account", PrimaryButton)),
+		"profile": QuickButtons(QuickButton("Edit profile", "profile", PrimaryButton)),
+		"finish":  QuickButtons(QuickButton("Finish", "finish", SuccessButton)),
+	}
+	order := []string{"account", "profile", "finish"}
+
+	wizard, err := QuickWizard("signup", steps, order)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wizard.DefaultTab != "account" {
+		t.Errorf("wizard.DefaultTab = %q, want %q", wizard.DefaultTab, "account")
+	}
+	if len(wizard.TabList) != len(order) {
+		t.Fatalf("len(wizard.TabList) = %d, want %d", len(wizard.TabList), len(order))
+	}
+	for i, id := range order {
+		if wizard.TabList[i].ID != id {
+			t.Errorf("wizard.TabList[%d].ID = %q, want %q", i, wizard.TabList[i].ID, id)
+		}
+		if want := fmt.Sprintf("Step %d", i+1); wizard.TabList[i].Label != want {
+			t.Errorf("wizard.TabList[%d].Label = %q, want %q", i, wizard.TabList[i].Label, want)
+		}
+	}
+
+	wizard, err = QuickWizard("signup", steps, []string{"account", "missing", "finish"})
+	if !errors.Is(err, ErrInvalidChild) {
+		t.Errorf("expected ErrInvalidChild for a missing step, got %v", err)
+	}
+	if len(wizard.TabList) != 0 {
+		t.Errorf("expected empty tabs on error, got %+v", wizard.TabList)
+	}
+}
+
+func TestValidateComponentCount(t *testing.T) {
+	// 8 rows of 5 buttons is 48 components
+	rows := make([]MessageComponent, 0, 8)
+	for i := 0; i < 8; i++ {
+		buttons := make([]Button, 5)
+		for j := range buttons {
+			buttons[j] = QuickButton("B", fmt.Sprintf("b_%d_%d", i, j), PrimaryButton)
+		}
+		rows = append(rows, QuickButtons(buttons...))
+	}
+
+	// 6 rows of 5 buttons is 36 components
+	if err := ValidateComponentCount(rows[:6]); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// 6 full rows plus a row of 4 buttons is 41 components
+	over := append(append([]MessageComponent{}, rows[:6]...), QuickButtons(
+		QuickButton("B", "x1", PrimaryButton),
+		QuickButton("B", "x2", PrimaryButton),
+		QuickButton("B", "x3", PrimaryButton),
+		QuickButton("B", "x4", PrimaryButton),
+	))
+	err := ValidateComponentCount(over)
+	if err == nil {
+		t.Fatal("expected an error for 41 components")
+	}
+	if !strings.Contains(err.Error(), "41") {
+		t.Errorf("error %q does not report the count", err)
+	}
+}
+
+func TestSelectMenuOptionDefaultOmitted(t *testing.T) {
+	b, err := json.Marshal(QuickOption("Red", "red", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), `"default"`) {
+		t.Errorf("non-default option marshaled with default key: %s", b)
+	}
+
+	option := QuickOption("Blue", "blue", "")
+	option.Default = true
+	b, err = json.Marshal(option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"default":true`) {
+		t.Errorf("default option missing \"default\":true: %s", b)
+	}
+}
+
+func TestQuickProgressBar(t *testing.T) {
+	bar := QuickProgressBar("Upload", 50)
+	if len(bar.Components) != 1 {
+		t.Fatalf("len(bar.Components) = %d, want 1", len(bar.Components))
+	}
+	text := bar.Components[0].(TextDisplay).Content
+	if n := strings.Count(text, "█"); n != 5 {
+		t.Errorf("filled blocks = %d, want 5", n)
+	}
+	if n := strings.Count(text, "░"); n != 5 {
+		t.Errorf("empty blocks = %d, want 5", n)
+	}
+	if !strings.Contains(text, "50%") || !strings.HasPrefix(text, "Upload") {
+		t.Errorf("unexpected progress text %q", text)
+	}
+
+	clamped := QuickProgressBar("Upload", 250).Components[0].(TextDisplay).Content
+	if strings.Count(clamped, "█") != 10 || !strings.Contains(clamped, "100%") {
+		t.Errorf("percent was not clamped: %q", clamped)
+	}
+}
+
+func TestValidateForSurface(t *testing.T) {
+	button := QuickButton("Go", "go", PrimaryButton)
+	if err := ValidateForSurface(button, SurfaceModal); err == nil {
+		t.Error("expected a button to be rejected in a modal")
+	}
+	if err := ValidateForSurface(button, SurfaceMessage); err != nil {
+		t.Errorf("unexpected error for a button in a message: %v", err)
+	}
+
+	modal := NewBuilder().Modal("feedback", "Feedback").
+		AddComponent(QuickButtons(QuickButton("Go", "go", PrimaryButton))).
+		Build()
+	err := ValidateForSurface(modal, SurfaceModal)
+	if err == nil || !strings.HasPrefix(err.Error(), "components[0].components[0]") {
+		t.Errorf("expected a nested button error with its path, got %v", err)
+	}
+	if err := ValidateForSurface(modal, SurfaceMessage); err == nil {
+		t.Error("expected a modal to be rejected in a message")
+	}
+}
+
+func TestValidateForSurfaceStopsAtFirstError(t *testing.T) {
+	// A valid row after the invalid one must not clear the error
+	modal := NewBuilder().Modal("feedback", "Feedback").
+		AddComponent(QuickButtons(QuickButton("Go", "go", PrimaryButton))).
+		AddTextInput(NewBuilder().TextInput("comment", "Comment").Build()).
+		Build()
+	err := ValidateForSurface(modal, SurfaceModal)
+	if err == nil || !strings.HasPrefix(err.Error(), "components[0].components[0]") {
+		t.Errorf("expected the button error to survive the walk, got %v", err)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	var row ActionsRow
+	err := json.Unmarshal([]byte(`{"type":1,"components":[
+		{"type":2,"label":"Keep","style":1,"custom_id":"keep"},
+		{"type":2,"label":"Delete","style":1,"custom_id":"delete"}
+	]}`), &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := Patch(row, "delete", func(c MessageComponent) MessageComponent {
+		b := deref(c).(Button)
+		b.Style = DangerButton
+		return b
+	})
+	if !found {
+		t.Fatal("Patch did not find the button")
+	}
+	if Patch(row, "missing", func(c MessageComponent) MessageComponent { return c }) {
+		t.Error("Patch reported a match for an unknown custom ID")
+	}
+
+	b, err := json.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ActionsRow
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if style := decoded.Components[1].(*Button).Style; style != DangerButton {
+		t.Errorf("patched style = %d, want %d", style, DangerButton)
+	}
+	if style := decoded.Components[0].(*Button).Style; style != PrimaryButton {
+		t.Errorf("untouched style = %d, want %d", style, PrimaryButton)
+	}
+}
+
+func TestPatchGroupAndAccessory(t *testing.T) {
+	group := ButtonsToGroup(QuickButton("Keep", "keep", PrimaryButton))
+	if Patch(group, "keep", func(MessageComponent) MessageComponent { return NewTextDisplay("not a button") }) {
+		t.Error("Patch reported replacing a group button with a text display")
+	}
+	if group.Buttons[0].Label != "Keep" {
+		t.Errorf("group button was changed to %+v", group.Buttons[0])
+	}
+
+	section := NewSection()
+	section.Components = []MessageComponent{NewTextDisplay("Settings")}
+	section.Accessory = QuickButton("Edit", "edit", PrimaryButton)
+	rename := func(c MessageComponent) MessageComponent {
+		b := deref(c).(Button)
+		b.Label = "Change"
+		return b
+	}
+	if Patch(*section, "edit", rename) {
+		t.Error("Patch reported replacing the accessory of a section held by value")
+	}
+	if !Patch(section, "edit", rename) || section.Accessory.(Button).Label != "Change" {
+		t.Errorf("expected the accessory to be patched through a pointer, got %+v", section.Accessory)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	row := NewBuilder().ActionsRow()
+	for i := 0; i < 5; i++ {
+		row.AddButton(QuickButton("B", fmt.Sprintf("b%d", i), PrimaryButton))
+	}
+	if row.Err() != nil {
+		t.Fatalf("unexpected error: %v", row.Err())
+	}
+	row.AddButton(QuickButton("B", "b5", PrimaryButton))
+	if !errors.Is(row.Err(), ErrBuilderOverflow) {
+		t.Errorf("row.Err() = %v, want ErrBuilderOverflow", row.Err())
+	}
+	if n := len(row.Build().Components); n != 5 {
+		t.Errorf("len(Components) = %d, want 5", n)
+	}
+
+	nested := NewBuilder().ActionsRow().AddComponent(QuickButtons())
+	if !errors.Is(nested.Err(), ErrInvalidChild) {
+		t.Errorf("nested.Err() = %v, want ErrInvalidChild", nested.Err())
+	}
+}
+
+func TestDefaultChannelTyped(t *testing.T) {
+	menu := NewBuilder().SelectMenu("channel").
+		ChannelSelect(ChannelTypeGuildText).
+		DefaultChannelTyped("111", ChannelTypeGuildText)
+	if menu.Err() != nil {
+		t.Fatalf("unexpected error: %v", menu.Err())
+	}
+
+	menu.DefaultChannelTyped("222", ChannelTypeGuildVoice)
+	if menu.Err() == nil {
+		t.Error("expected an error for a voice channel default in a text-only select")
+	}
+	built := menu.Build()
+	if len(built.DefaultValues) != 1 || built.DefaultValues[0].Type != SelectMenuDefaultValueChannel {
+		t.Errorf("unexpected default values: %+v", built.DefaultValues)
+	}
+}
+
+func TestFieldCoverage(t *testing.T) {
+	badge := 3
+	button := Button{
+		Label:     "Buy",
+		Style:     PremiumButton,
+		Disabled:  true,
+		Emoji:     &ComponentEmoji{Name: "💎"},
+		URL:       "https://example.com",
+		CustomID:  "buy",
+		SKUID:     "123456789012345678",
+		ID:        1,
+		Tooltip:   "Buy now",
+		Badge:     &badge,
+		BadgeText: "New",
+		Loading:   true,
+		Size:      ButtonSizeLarge,
+	}
+
+	coverage := FieldCoverage(button)
+	if len(coverage) == 0 {
+		t.Fatal("FieldCoverage returned no fields")
+	}
+	for field, ok := range coverage {
+		if !ok {
+			t.Errorf("field %q was dropped when marshaling", field)
+		}
+	}
+	if !coverage["sku_id"] || !coverage["tooltip"] {
+		t.Errorf("expected sku_id and tooltip in coverage: %v", coverage)
+	}
+}
+
+func TestParseMessageComponents(t *testing.T) {
+	raw := json.RawMessage(`[
+		{
+			"type": 1,
+			"id": 1,
+			"components": [
+				{"type": 2, "id": 2, "style": 1, "label": "Accept", "custom_id": "accept"},
+				{"type": 2, "id": 3, "style": 5, "label": "Docs", "url": "https://example.com"}
+			]
+		},
+		{
+			"type": 1,
+			"id": 4,
+			"components": [
+				{
+					"type": 3,
+					"id": 5,
+					"custom_id": "color",
+					"placeholder": "Pick a color",
+					"options": [
+						{"label": "Red", "value": "red", "description": ""},
+						{"label": "Blue", "value": "blue", "description": "", "default": true}
+					],
+					"min_values": 1,
+					"max_values": 1
+				}
+			]
+		}
+	]`)
+
+	components, err := ParseMessageComponents(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 2 {
+		t.Fatalf("len(components) = %d, want 2", len(components))
+	}
+
+	first := components[0].(*ActionsRow)
+	if len(first.Components) != 2 {
+		t.Fatalf("len(first.Components) = %d, want 2", len(first.Components))
+	}
+	if b := first.Components[1].(*Button); b.Style != LinkButton || b.URL != "https://example.com" {
+		t.Errorf("unexpected link button: %+v", b)
+	}
+
+	menu := components[1].(*ActionsRow).Components[0].(*SelectMenu)
+	if menu.CustomID != "color" || len(menu.Options) != 2 || !menu.Options[1].Default {
+		t.Errorf("unexpected select menu: %+v", menu)
+	}
+
+	if _, err := ParseMessageComponents(json.RawMessage(`[{"type":999}]`)); err == nil {
+		t.Error("expected an error for an unknown component type")
+	}
+}
+
+func TestMap(t *testing.T) {
+	container := Container{
+		Components: []MessageComponent{
+			TextDisplay{Content: "Choose"},
+			QuickButtons(
+				QuickButton("yes", "yes", SuccessButton),
+				QuickButton("no", "no", DangerButton),
+			),
+			Section{
+				Components: []MessageComponent{TextDisplay{Content: "Not sure yet?"}},
+				Accessory:  QuickButton("later", "later", SecondaryButton),
+			},
+		},
+	}
+	if err := validateTree(container); err != nil {
+		t.Fatalf("fixture is invalid: %v", err)
+	}
+
+	upper := Map(container, func(c MessageComponent) MessageComponent {
+		if b, ok := c.(Button); ok {
+			b.Label = strings.ToUpper(b.Label)
+			return b
+		}
+		return c
+	}).(Container)
+
+	var labels []string
+	walkComponents(upper, "", nil, func(path string, parent, c MessageComponent) bool {
+		if b, ok := c.(Button); ok {
+			labels = append(labels, b.Label)
+		}
+		return true
+	})
+	if strings.Join(labels, ",") != "YES,NO,LATER" {
+		t.Errorf("labels = %v, want [YES NO LATER]", labels)
+	}
+	if accessory := upper.Components[2].(Section).Accessory.(Button); accessory.Label != "LATER" {
+		t.Errorf("accessory label = %q, want LATER", accessory.Label)
+	}
+	if container.Components[1].(ActionsRow).Components[0].(Button).Label != "yes" ||
+		container.Components[2].(Section).Accessory.(Button).Label != "later" {
+		t.Error("Map modified its input")
+	}
+}
+
+func TestMaskedTextInputValue(t *testing.T) {
+	input := NewBuilder().TextInput("password", "Password").Masked(true).Value("hunter2").Build()
+	if err := ValidateComponent(input); err == nil {
+		t.Error("expected an error for a masked input with a value")
+	}
+
+	modal := NewBuilder().Modal("login", "Log in").AddTextInput(input).Build()
+	b, err := json.Marshal(StripMaskedValues(modal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "hunter2") {
+		t.Errorf("masked value leaked into JSON: %s", b)
+	}
+	if input.Value != "hunter2" {
+		t.Error("StripMaskedValues modified its input")
+	}
+}
+
+func TestQuickToggle(t *testing.T) {
+	on := QuickToggle("notifications", "Notifications", true)
+	if on.Style != SuccessButton {
+		t.Errorf("on.Style = %d, want %d", on.Style, SuccessButton)
+	}
+	if on.CustomID != "notifications_toggle" {
+		t.Errorf("on.CustomID = %q, want %q", on.CustomID, "notifications_toggle")
+	}
+	if off := QuickToggle("notifications", "Notifications", false); off.Style != SecondaryButton {
+		t.Errorf("off.Style = %d, want %d", off.Style, SecondaryButton)
+	}
+}
+
+func TestCloneWithPrefix(t *testing.T) {
+	row := QuickButtons(
+		NewBuilder().Button("Fire").CustomID("fire").Emoji("🔥", "", false).Build(),
+		QuickButton("Ice", "ice", SecondaryButton),
+		NewBuilder().Button("Docs").Link("https://example.com").Build(),
+	)
+
+	cloned := CloneWithPrefix(row, "guild42").(ActionsRow)
+	for i, want := range []string{"guild42:fire", "guild42:ice", ""} {
+		if got := cloned.Components[i].(Button).CustomID; got != want {
+			t.Errorf("Components[%d].CustomID = %q, want %q", i, got, want)
+		}
+	}
+
+	cloned.Components[0].(Button).Emoji.Name = "💧"
+	if row.Components[0].(Button).Emoji.Name != "🔥" {
+		t.Error("clone shares its emoji with the original")
+	}
+	if row.Components[0].(Button).CustomID != "fire" {
+		t.Error("CloneWithPrefix modified its input")
+	}
+}
+
+func TestValidateModalRows(t *testing.T) {
+	builder := NewBuilder().Modal("survey", "Survey")
+	for i := 0; i < 5; i++ {
+		builder.AddTextInput(NewBuilder().TextInput(fmt.Sprintf("q%d", i), "Question").Build())
+	}
+	if err := ValidateComponent(builder.Build()); err != nil {
+		t.Fatalf("unexpected error for 5 rows: %v", err)
+	}
+
+	builder.AddTextInput(NewBuilder().TextInput("q5", "Question").Build())
+	if err := ValidateComponent(builder.Build()); err == nil {
+		t.Error("expected an error for 6 rows")
+	}
+
+	withButton := NewBuilder().Modal("survey", "Survey").
+		AddComponent(QuickButtons(QuickButton("Go", "go", PrimaryButton))).
+		Build()
+	if err := ValidateComponent(withButton); err == nil {
+		t.Error("expected an error for a button row in a modal")
+	}
+
+	bare := Modal{CustomID: "survey", Title: "Survey", Components: []MessageComponent{
+		QuickButton("Go", "go", PrimaryButton),
+		QuickSelectMenu("pick", "Pick one", QuickOption("One", "1", "")),
+	}}
+	if err := ValidateComponent(bare); err == nil {
+		t.Error("expected an error for a bare button in a modal")
+	}
+	if err := ValidateModal(bare); err == nil {
+		t.Error("expected ValidateModal to agree with ValidateComponent")
+	}
+}
+
+func TestMediaGalleryBuilder(t *testing.T) {
+	gallery := NewBuilder().MediaGallery().
+		AddItem("https://example.com/cat.png").
+		AddItem("https://example.com/spoiler.png", WithSpoiler(), WithDescription("Ending")).
+		Build()
+
+	if len(gallery.Items) != 2 {
+		t.Fatalf("len(gallery.Items) = %d, want 2", len(gallery.Items))
+	}
+	if gallery.Items[0].Spoiler {
+		t.Error("plain item was marked as a spoiler")
+	}
+	item := gallery.Items[1]
+	if !item.Spoiler || item.Description != "Ending" || item.Media.URL != "https://example.com/spoiler.png" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+}
+
+func TestChangedIDs(t *testing.T) {
+	old := QuickButtons(
+		QuickButton("One", "one", PrimaryButton),
+		QuickButton("Two", "two", PrimaryButton),
+		QuickButton("Three", "three", PrimaryButton),
+	)
+	new := QuickButtons(
+		QuickButton("One", "one", PrimaryButton),
+		QuickButton("Two!", "two", PrimaryButton),
+		QuickButton("Three", "three", PrimaryButton),
+	)
+
+	changed := ChangedIDs(old, new)
+	if len(changed) != 1 || changed[0] != "two" {
+		t.Errorf("ChangedIDs = %v, want [two]", changed)
+	}
+	if changed := ChangedIDs(old, old); len(changed) != 0 {
+		t.Errorf("ChangedIDs of identical trees = %v, want none", changed)
+	}
+}
+
+func TestButtonGroupLayout(t *testing.T) {
+	group := NewBuilder().ButtonGroup().
+		AddButton(QuickButton("A", "a", PrimaryButton)).
+		AddButton(QuickButton("B", "b", PrimaryButton)).
+		Vertical().
+		Wrap(true).
+		Build()
+	if !group.Vertical || !group.Wrap {
+		t.Errorf("group.Vertical = %v, group.Wrap = %v, want true, true", group.Vertical, group.Wrap)
+	}
+
+	b, err := json.Marshal(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"vertical":true`) || !strings.Contains(string(b), `"wrap":true`) {
+		t.Errorf("layout fields missing from JSON: %s", b)
+	}
+
+	b, err = json.Marshal(ButtonsToGroup(QuickButton("A", "a", PrimaryButton)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "vertical") || strings.Contains(string(b), "wrap") {
+		t.Errorf("unset layout fields should be omitted: %s", b)
+	}
+}
+
+func TestQuickHeader(t *testing.T) {
+	header, err := QuickHeader("Server Stats", "https://example.com/icon.png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(header.Components) != 1 {
+		t.Fatalf("len(header.Components) = %d, want 1", len(header.Components))
+	}
+	if text := header.Components[0].(TextDisplay).Content; !strings.Contains(text, "Server Stats") {
+		t.Errorf("heading = %q, want it to contain the title", text)
+	}
+	thumb, ok := header.Accessory.(Thumbnail)
+	if !ok {
+		t.Fatalf("header.Accessory is %T, want Thumbnail", header.Accessory)
+	}
+	if thumb.Media.URL != "https://example.com/icon.png" {
+		t.Errorf("thumbnail URL = %q", thumb.Media.URL)
+	}
+	if issues := ValidateReport(header); len(issues) != 0 {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+
+	for _, url := range []string{"not a url", "javascript:alert(1)", ""} {
+		if _, err := QuickHeader("Broken", url); err == nil || !strings.Contains(err.Error(), "thumbnail") {
+			t.Errorf("QuickHeader(%q): expected a thumbnail error, got %v", url, err)
+		}
+	}
+}
+
+func TestDefaultUsers(t *testing.T) {
+	menu := NewBuilder().SelectMenu("members").UserSelect().MaxValues(3).
+		DefaultUsers("1", "2", "3")
+	if menu.Err() != nil {
+		t.Fatalf("unexpected error: %v", menu.Err())
+	}
+	built := menu.Build()
+	if len(built.DefaultValues) != 3 {
+		t.Fatalf("len(DefaultValues) = %d, want 3", len(built.DefaultValues))
+	}
+	for i, v := range built.DefaultValues {
+		if v.ID != fmt.Sprint(i+1) || v.Type != SelectMenuDefaultValueUser {
+			t.Errorf("DefaultValues[%d] = %+v", i, v)
+		}
+	}
+
+	menu.DefaultUsers("4")
+	if !errors.Is(menu.Err(), ErrBuilderOverflow) {
+		t.Errorf("menu.Err() = %v, want ErrBuilderOverflow", menu.Err())
+	}
+}
+
+func TestEstimatedMinSize(t *testing.T) {
+	b, err := json.Marshal(Separator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EstimatedMinSize(SeparatorComponent); got != len(b) {
+		t.Errorf("EstimatedMinSize(SeparatorComponent) = %d, want %d", got, len(b))
+	}
+	if got := EstimatedMinSize(ButtonComponent); got <= EstimatedMinSize(SeparatorComponent) {
+		t.Errorf("EstimatedMinSize(ButtonComponent) = %d, expected more than a separator", got)
+	}
+	if got := EstimatedMinSize(ComponentType(999)); got != 0 {
+		t.Errorf("EstimatedMinSize(999) = %d, want 0", got)
+	}
+}
+
+func TestQuickConfirmWithDelay(t *testing.T) {
+	before := time.Now()
+	row, enableAt := QuickConfirmWithDelay("delete", 5*time.Second)
+
+	yes := row.Components[0].(Button)
+	if !yes.Disabled || yes.CustomID != "delete_yes" {
+		t.Errorf("unexpected yes button: %+v", yes)
+	}
+	if no := row.Components[1].(Button); no.Disabled {
+		t.Error("no button should start enabled")
+	}
+	if enableAt.Before(before.Add(5 * time.Second)) {
+		t.Errorf("enableAt = %v, want at least 5s after %v", enableAt, before)
+	}
+}
+
+func TestGraphemeLabelLength(t *testing.T) {
+	family := "👨‍👩‍👧‍👦"
+	if n := graphemeCount(family); n != 1 || n >= utf8.RuneCountInString(family) {
+		t.Errorf("graphemeCount(family) = %d, runes = %d", n, utf8.RuneCountInString(family))
+	}
+	for s, want := range map[string]int{
+		"abc":           3,
+		"🇫🇷🇩🇪":          2,
+		"1\ufe0f\u20e3": 1,
+		"👍🏽":            1,
+		"e\u0301":       1,
+		"a\r\nb":        3,
+	} {
+		if n := graphemeCount(s); n != want {
+			t.Errorf("graphemeCount(%q) = %d, want %d", s, n, want)
+		}
+	}
+
+	button := QuickButton(strings.Repeat(family, maxButtonLabelLength), "family", PrimaryButton)
+	if err := ValidateComponent(button); err != nil {
+		t.Errorf("unexpected error for %d emoji: %v", maxButtonLabelLength, err)
+	}
+	button.Label += family
+	if err := ValidateComponent(button); err == nil {
+		t.Error("expected an error for an over-long label")
+	}
+
+	menu := QuickSelectMenu("menu", "", QuickOption(strings.Repeat("x", maxOptionLabelLength+1), "x", ""))
+	if err := ValidateComponent(menu); err == nil {
+		t.Error("expected an error for an over-long option label")
+	}
+}
+
+func TestStubButton(t *testing.T) {
+	stub := StubButton("vote_42")
+	if stub.CustomID != "vote_42" || !stub.Disabled || stub.Style != SecondaryButton {
+		t.Errorf("unexpected stub: %+v", stub)
+	}
+	if stub.Label != StubButtonLabel {
+		t.Errorf("stub.Label = %q, want %q", stub.Label, StubButtonLabel)
+	}
+
+	stub.Label = "Voted"
+	if err := ValidateComponent(stub); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestStrictPlaceholderWarning(t *testing.T) {
+	menu := QuickSelectMenu("color", "", QuickOption("Red", "red", ""), QuickOption("Blue", "blue", ""))
+	if issues := ValidateReport(menu); len(issues) != 0 {
+		t.Errorf("unexpected issues without strict validation: %v", issues)
+	}
+
+	issues := ValidateReport(menu, WithStrictValidation(true))
+	if len(issues) != 1 || issues[0].Severity != IssueWarning || !strings.Contains(issues[0].Message, "placeholder") {
+		t.Errorf("expected a placeholder warning, got %v", issues)
+	}
+
+	menu.Placeholder = "Pick a color"
+	if issues := ValidateReport(menu, WithStrictValidation(true)); len(issues) != 0 {
+		t.Errorf("unexpected issues with a placeholder: %v", issues)
+	}
+}
+
+func TestCustomIDs(t *testing.T) {
+	layout := Container{Components: []MessageComponent{
+		QuickButtons(
+			QuickButton("A", "a", PrimaryButton),
+			NewBuilder().Button("Docs").Link("https://example.com").Build(),
+			QuickButton("B", "b", PrimaryButton),
+		),
+		QuickButtons(
+			QuickButton("C", "c", PrimaryButton),
+			QuickButton("A again", "a", PrimaryButton),
+		),
+	}}
+
+	ids := CustomIDs(layout)
+	if strings.Join(ids, ",") != "a,b,c" {
+		t.Errorf("CustomIDs = %v, want [a b c]", ids)
+	}
+}
+
+func TestQuickLabeledSeparator(t *testing.T) {
+	components := QuickLabeledSeparator("Settings")
+	if len(components) != 2 {
+		t.Fatalf("len(components) = %d, want 2", len(components))
+	}
+	if text, ok := components[0].(TextDisplay); !ok || text.Content != "Settings" {
+		t.Errorf("components[0] = %#v, want TextDisplay with the label", components[0])
+	}
+	if sep, ok := components[1].(Separator); !ok || !sep.Divider {
+		t.Errorf("components[1] = %#v, want Separator with a divider", components[1])
+	}
+}
+
+func TestAutoFix(t *testing.T) {
+	bare := QuickButton(strings.Repeat("x", 200), "long", PrimaryButton)
+
+	fixed, fixes := AutoFix([]MessageComponent{bare})
+	if len(fixes) != 2 {
+		t.Errorf("fixes = %v, want 2", fixes)
+	}
+	if len(fixed) != 1 {
+		t.Fatalf("len(fixed) = %d, want 1", len(fixed))
+	}
+	row, ok := fixed[0].(ActionsRow)
+	if !ok {
+		t.Fatalf("fixed[0] is %T, want ActionsRow", fixed[0])
+	}
+	if label := row.Components[0].(Button).Label; len(label) != maxButtonLabelLength {
+		t.Errorf("len(label) = %d, want %d", len(label), maxButtonLabelLength)
+	}
+	if len(bare.Label) != 200 {
+		t.Error("AutoFix modified its input")
+	}
+	for _, c := range fixed {
+		if err := ValidateComponent(c); err != nil {
+			t.Errorf("fixed component is still invalid: %v", err)
+		}
+	}
+}
+
+func TestContainerSectionRoundTrip(t *testing.T) {
+	container := Container{Components: []MessageComponent{
+		Section{
+			Components: []MessageComponent{TextDisplay{Content: "Hello"}},
+			Accessory:  QuickButton("Wave", "wave", SecondaryButton),
+		},
+	}}
+
+	b, err := json.Marshal(container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MessageComponentFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := decoded.(*Container)
+	if !ok || len(c.Components) != 1 {
+		t.Fatalf("decoded %#v, want a container with one child", decoded)
+	}
+	section, ok := c.Components[0].(*Section)
+	if !ok || len(section.Components) != 1 {
+		t.Fatalf("decoded child %#v, want a section with one text display", c.Components[0])
+	}
+	if text, ok := section.Components[0].(*TextDisplay); !ok || text.Content != "Hello" {
+		t.Errorf("decoded section text = %#v", section.Components[0])
+	}
+	if button, ok := section.Accessory.(*Button); !ok || button.CustomID != "wave" {
+		t.Errorf("decoded accessory = %#v", section.Accessory)
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Errorf("round trip changed JSON:\n%s\n%s", b, again)
+	}
+}
+
+func TestEmptyContainerMarshal(t *testing.T) {
+	b, err := json.Marshal(Container{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "null") {
+		t.Errorf("childless container marshaled with null: %s", b)
+	}
+	b, err = json.Marshal(Section{Components: []MessageComponent{TextDisplay{Content: "Hi"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "accessory") {
+		t.Errorf("section without accessory marshaled one: %s", b)
+	}
+}
+
+func TestNewButton(t *testing.T) {
+	button := NewButton()
+	if button == nil {
+		t.Fatal("NewButton returned nil")
+	}
+	button.Label = "Go"
+	button.CustomID = "go"
+
+	b, err := json.Marshal(button)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MessageComponentFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := decoded.(*Button)
+	if !ok {
+		t.Fatalf("decoded %T, want *Button", decoded)
+	}
+	if d.Style != PrimaryButton || !reflect.DeepEqual(d, button) {
+		t.Errorf("decoded %+v, want %+v", d, button)
+	}
+}
+
+func TestNewSelectMenuMatchesBuilder(t *testing.T) {
+	menu := NewSelectMenu()
+	menu.CustomID = "color"
+	if built := NewBuilder().SelectMenu("color").Build(); !reflect.DeepEqual(*menu, built) {
+		t.Errorf("NewSelectMenu = %+v, builder = %+v", *menu, built)
+	}
+}
+
+func TestAddValidatedTab(t *testing.T) {
+	tabs := NewBuilder().Tabs("settings")
+	if err := tabs.AddValidatedTab("general", "General", QuickButtons(QuickButton("Save", "save", SuccessButton))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := QuickButtons(QuickButton("No ID", "", PrimaryButton), QuickButton("Fine", "fine", PrimaryButton))
+	if err := tabs.AddValidatedTab("broken", "Broken", invalid); err == nil {
+		t.Error("expected an error for a tab containing an invalid button")
+	}
+	if n := len(tabs.Build().TabList); n != 1 {
+		t.Errorf("len(TabList) = %d, want 1", n)
+	}
+}
+
+func TestValidateSelectCount(t *testing.T) {
+	var rows []MessageComponent
+	for i := 0; i < 6; i++ {
+		menu := QuickSelectMenu(fmt.Sprintf("menu_%d", i), "Pick", QuickOption("A", "a", ""))
+		rows = append(rows, ActionsRow{Components: []MessageComponent{menu}})
+	}
+
+	if err := ValidateSelectCount(rows[:5]); err != nil {
+		t.Errorf("unexpected error for 5 selects: %v", err)
+	}
+	if err := ValidateSelectCount(rows); err == nil {
+		t.Error("expected an error for 6 selects")
+	}
+}
+
+func TestLinkButtonCustomID(t *testing.T) {
+	bb := NewBuilder().Button("Docs").CustomID("docs").Link("https://example.com")
+	if bb.Err() != nil {
+		t.Fatalf("unexpected error: %v", bb.Err())
+	}
+	if id := bb.Build().CustomID; id != "" {
+		t.Errorf("Link did not clear the custom ID, got %q", id)
+	}
+
+	bb.CustomID("docs")
+	if bb.Err() == nil {
+		t.Error("expected an error for a custom ID on a link button")
+	}
+	if id := bb.Build().CustomID; id != "" {
+		t.Errorf("custom ID was set on a link button: %q", id)
+	}
+}
+
+func TestValueWithBounds(t *testing.T) {
+	value := strings.Repeat("a", 50)
+	input := NewBuilder().TextInput("bio", "Bio").MaxLength(20).ValueWithBounds(value).Build()
+	if input.Value != value {
+		t.Errorf("input.Value = %q, want %q", input.Value, value)
+	}
+	if input.MaxLength < 50 {
+		t.Errorf("input.MaxLength = %d, want at least 50", input.MaxLength)
+	}
+
+	roomy := NewBuilder().TextInput("bio", "Bio").MaxLength(200).ValueWithBounds(value).Build()
+	if roomy.MaxLength != 200 {
+		t.Errorf("roomy.MaxLength = %d, want 200", roomy.MaxLength)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	shared := QuickConfirmDialog("confirm")
+	RegisterTemplate("confirm", func() MessageComponent { return shared })
+
+	first, ok := Template("confirm")
+	if !ok {
+		t.Fatal("template not found")
+	}
+	second, _ := Template("confirm")
+
+	Patch(first, "confirm_yes", func(c MessageComponent) MessageComponent {
+		b := deref(c).(Button)
+		b.Label = "Sure"
+		return b
+	})
+	if label := second.(ActionsRow).Components[0].(Button).Label; label != "Yes" {
+		t.Errorf("second copy label = %q, want %q", label, "Yes")
+	}
+	if label := shared.Components[0].(Button).Label; label != "Yes" {
+		t.Errorf("template source label = %q, want %q", label, "Yes")
+	}
+
+	if _, ok := Template("missing"); ok {
+		t.Error("Template reported an unregistered name")
+	}
+}
+
+func TestValidateMediaItem(t *testing.T) {
+	for _, u := range []string{"https://example.com/a.png", "http://example.com/a.png", "attachment://a.png"} {
+		if err := validateMediaItem(UnfurledMediaItem{URL: u}); err != nil {
+			t.Errorf("unexpected error for %q: %v", u, err)
+		}
+	}
+
+	ftp := UnfurledMediaItem{URL: "ftp://example.com/a.png"}
+	for _, c := range []MessageComponent{
+		Thumbnail{Media: ftp},
+		MediaGallery{Items: []MediaGalleryItem{{Media: ftp}}},
+		FileComponent{File: ftp},
+	} {
+		if err := ValidateComponent(c); err == nil {
+			t.Errorf("expected %T with an ftp:// URL to be rejected", c)
+		}
+	}
+}
+
+func TestValidateNavIDs(t *testing.T) {
+	text := TextDisplay{Content: "x"}
+	inner := NewBuilder().Tabs("inner").AddTab("general", "General", text).Build()
+	outer := NewBuilder().Tabs("outer").
+		AddTab("general", "General", inner).
+		AddTab("advanced", "Advanced", text).
+		Build()
+	if err := ValidateNavIDs(outer); err != nil {
+		t.Errorf("unexpected error for IDs shared across parents: %v", err)
+	}
+
+	duplicate := NewBuilder().Tabs("dup").
+		AddTab("general", "General", text).
+		AddTab("general", "General again", text).
+		Build()
+	nested := Container{Components: []MessageComponent{duplicate}}
+	err := ValidateNavIDs(nested)
+	if err == nil {
+		t.Fatal("expected an error for duplicate tab IDs")
+	}
+	if !strings.Contains(err.Error(), "components[0].tabs[0]") || !strings.Contains(err.Error(), "components[0].tabs[1]") {
+		t.Errorf("error %q does not report the colliding paths", err)
+	}
+}
+
+func TestChannelTypesByName(t *testing.T) {
+	smb := NewBuilder().SelectMenu("channels").ChannelTypesByName("text", "Voice")
+	if smb.Err() != nil {
+		t.Fatalf("unexpected error: %v", smb.Err())
+	}
+	menu := smb.Build()
+	if menu.MenuType != ChannelSelectMenu {
+		t.Errorf("menu.MenuType = %d, want %d", menu.MenuType, ChannelSelectMenu)
+	}
+	if len(menu.ChannelTypes) != 2 || menu.ChannelTypes[0] != ChannelTypeGuildText || menu.ChannelTypes[1] != ChannelTypeGuildVoice {
+		t.Errorf("menu.ChannelTypes = %v", menu.ChannelTypes)
+	}
+
+	if NewBuilder().SelectMenu("channels").ChannelTypesByName("text", "hallway").Err() == nil {
+		t.Error("expected an error for an unknown channel type name")
+	}
+}
+
+func TestButtonSnapshot(t *testing.T) {
+	bb := NewBuilder().Button("Save").CustomID("save").Emoji("💾", "", false)
+	snapshot := bb.Snapshot()
+
+	bb.Danger().Disabled(true).Emoji("🗑️", "", false).Link("https://example.com").CustomID("oops")
+	if bb.Err() == nil {
+		t.Fatal("expected the mutation to record an error")
+	}
+
+	restored := bb.Restore(snapshot).Build()
+	if restored.Label != "Save" || restored.CustomID != "save" || restored.Style != PrimaryButton || restored.Disabled {
+		t.Errorf("unexpected restored button: %+v", restored)
+	}
+	if restored.Emoji == nil || restored.Emoji.Name != "💾" {
+		t.Errorf("restored emoji = %+v", restored.Emoji)
+	}
+	if bb.Err() != nil {
+		t.Errorf("restored builder still has error %v", bb.Err())
+	}
+}
+
+func TestBuildValidated(t *testing.T) {
+	if _, err := NewBuilder().Tabs("empty").BuildValidated(); err == nil {
+		t.Error("expected an error for empty tabs")
+	}
+	if _, err := NewBuilder().Accordion("empty").BuildValidated(); err == nil {
+		t.Error("expected an error for an empty accordion")
+	}
+
+	text := TextDisplay{Content: "x"}
+	if _, err := NewBuilder().Tabs("ok").AddTab("a", "A", text).BuildValidated(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	accordion, err := NewBuilder().Accordion("ok").AddItem("a", "A", text).Open("a").BuildValidated()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !accordion.Items[0].Open {
+		t.Error("item a should be open")
+	}
+
+	// Build stays lenient
+	if tabs := NewBuilder().Tabs("empty").Build(); len(tabs.TabList) != 0 {
+		t.Errorf("unexpected tabs: %+v", tabs)
+	}
+}
+
+func TestSplitOptions(t *testing.T) {
+	options := []SelectMenuOption{
+		QuickOption("Apple", "fruit:apple", ""),
+		QuickOption("Carrot", "veg:carrot", ""),
+		QuickOption("Banana", "fruit:banana", ""),
+		QuickOption("Leek", "veg:leek", ""),
+		QuickOption("Cherry", "fruit:cherry", ""),
+		QuickOption("Pea", "veg:pea", ""),
+	}
+
+	groups := SplitOptions(options, func(o SelectMenuOption) string {
+		return strings.SplitN(o.Value, ":", 2)[0]
+	})
+	if len(groups) != 2 {
+		t.Fatalf("len(groups) = %d, want 2", len(groups))
+	}
+	if fruit := groups["fruit"]; len(fruit) != 3 || fruit[0].Label != "Apple" || fruit[2].Label != "Cherry" {
+		t.Errorf("groups[fruit] = %+v", fruit)
+	}
+	if veg := groups["veg"]; len(veg) != 3 || veg[1].Label != "Leek" {
+		t.Errorf("groups[veg] = %+v", veg)
+	}
+}
+
+func TestPremiumButtonSKUID(t *testing.T) {
+	premium := Button{Style: PremiumButton, SKUID: "1088510058284990888"}
+	if err := ValidateComponent(premium); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	premium.SKUID = "premium-tier"
+	if err := ValidateComponent(premium); err == nil {
+		t.Error("expected an error for a non-numeric SKU ID")
+	}
+	premium.SKUID = ""
+	if err := ValidateComponent(premium); err == nil {
+		t.Error("expected an error for a missing SKU ID")
+	}
+}
+
+func TestEphemeralResponse(t *testing.T) {
+	b, err := EphemeralResponse(QuickConfirmDialog("delete"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v struct {
+		Type InteractionResponseType `json:"type"`
+		Data struct {
+			Flags      MessageFlags      `json:"flags"`
+			Components []json.RawMessage `json:"components"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Type != InteractionResponseChannelMessageWithSource {
+		t.Errorf("type = %d, want %d", v.Type, InteractionResponseChannelMessageWithSource)
+	}
+	if v.Data.Flags != 1<<6 {
+		t.Errorf("flags = %d, want %d", v.Data.Flags, 1<<6)
+	}
+	if len(v.Data.Components) != 1 || !strings.Contains(string(v.Data.Components[0]), "delete_yes") {
+		t.Errorf("unexpected components: %s", b)
+	}
+}
+
+func TestValidateAPIVersion(t *testing.T) {
+	container := Container{Components: []MessageComponent{TextDisplay{Content: "v2"}}}
+	if err := ValidateAPIVersion(container, 9); err == nil {
+		t.Error("expected a container to require a newer API than v9")
+	}
+	if err := ValidateAPIVersion(container, 10); err != nil {
+		t.Errorf("unexpected error for v10: %v", err)
+	}
+
+	row := QuickButtons(QuickButton("Go", "go", PrimaryButton))
+	if err := ValidateAPIVersion(row, 9); err != nil {
+		t.Errorf("unexpected error for a legacy row: %v", err)
+	}
+	if got := MinAPIVersion(UserSelectMenuComponent); got != 10 {
+		t.Errorf("MinAPIVersion(UserSelectMenuComponent) = %d, want 10", got)
+	}
+}
+
+func TestTextInputPatternCacheNotMarshaled(t *testing.T) {
+	input := NewBuilder().TextInput("age", "Age").Validation("[0-9]+").Build()
+	if !input.Matches("42") || input.Matches("abc") {
+		t.Fatal("pattern did not match as expected")
+	}
+	if input.compiled == nil || input.compiled.re == nil {
+		t.Fatal("pattern was not cached")
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(b, &keys); err != nil {
+		t.Fatal(err)
+	}
+	coverage := FieldCoverage(input)
+	for key := range keys {
+		if _, declared := coverage[key]; !declared && key != "type" {
+			t.Errorf("unexpected key %q in %s", key, b)
+		}
+	}
+}
+
+func TestUsedFeatures(t *testing.T) {
+	cb := NewBuilder()
+	layout := NewContainer(
+		NewActionsRow(cb.Button("Help").Primary().CustomID("help").Tooltip("Show help").Build()),
+		NewActionsRow(SelectMenu{MenuType: StringSelectMenu, CustomID: "pick", Grouped: true}),
+		NewActionsRow(cb.Button("Plain").Secondary().CustomID("plain").Build()),
+	)
+
+	got := UsedFeatures(layout)
+	want := []string{"button.tooltip", "select.grouped"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("UsedFeatures = %v, want %v", got, want)
+	}
+}
+
+func TestConfirmDialog(t *testing.T) {
+	row := ConfirmDialog("delete", ConfirmOptions{
+		YesLabel: "Oui",
+		NoLabel:  "Non",
+		NoEmoji:  &ComponentEmoji{Name: "✖️"},
+	})
+	if len(row.Components) != 2 {
+		t.Fatalf("got %d buttons, want 2", len(row.Components))
+	}
+	yes, no := row.Components[0].(Button), row.Components[1].(Button)
+	if yes.Label != "Oui" || yes.CustomID != "delete_yes" || yes.Style != SuccessButton {
+		t.Errorf("unexpected yes button %+v", yes)
+	}
+	if no.Label != "Non" || no.CustomID != "delete_no" || no.Style != DangerButton || no.Emoji == nil {
+		t.Errorf("unexpected no button %+v", no)
+	}
+
+	if got, want := QuickConfirmDialog("delete"), ConfirmDialog("delete", ConfirmOptions{}); !componentsEqual(got, want) {
+		t.Error("QuickConfirmDialog differs from ConfirmDialog defaults")
+	}
+}
+
+func TestValidateReportIDMix(t *testing.T) {
+	row := NewActionsRow(
+		QuickButtonWithID("Yes", "yes", SuccessButton, 7),
+		QuickButton("No", "no", DangerButton),
+	)
+
+	found := false
+	for _, issue := range ValidateReport(row) {
+		found = found || (issue.Severity == IssueWarning && strings.Contains(issue.Message, "AssignIDs"))
+	}
+	if !found {
+		t.Fatal("expected a warning about mixed IDs")
+	}
+
+	assigned := AssignIDs(row)
+	for _, issue := range ValidateReport(assigned) {
+		t.Errorf("unexpected issue after AssignIDs: %s", issue)
+	}
+	got := assigned.(ActionsRow)
+	if got.ID != 8 || got.Components[0].(Button).ID != 7 || got.Components[1].(Button).ID != 9 {
+		t.Errorf("unexpected IDs in %+v", got)
+	}
+	if row.ID != 0 {
+		t.Error("AssignIDs modified its input")
+	}
+}
+
+func TestPreviewBox(t *testing.T) {
+	box := PreviewBox(QuickConfirmDialog("delete"))
+	for _, want := range []string{"[ Yes ]", "[ No ]", "| [ Yes ] [ No ] |"} {
+		if !strings.Contains(box, want) {
+			t.Errorf("preview is missing %q:\n%s", want, box)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(box, "\n"), "\n")
+	if len(lines) != 3 || lines[0] != lines[2] || len(lines[0]) != len(lines[1]) {
+		t.Errorf("malformed box:\n%s", box)
+	}
+}
+
+func TestValidateModalPrefilledValue(t *testing.T) {
+	cb := NewBuilder()
+	input := cb.TextInput("age", "Age").Validation("[0-9]+").Value("abc").Build()
+	modal := cb.Modal("profile", "Profile").AddTextInput(input).Build()
+	if err := ValidateComponent(modal); err == nil || !strings.Contains(err.Error(), "validation pattern") {
+		t.Fatalf("expected a validation pattern error, got %v", err)
+	}
+
+	input.Value = "42"
+	modal = cb.Modal("profile", "Profile").AddTextInput(input).Build()
+	if err := ValidateComponent(modal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLabelRoundTrip(t *testing.T) {
+	cb := NewBuilder()
+	lb := cb.Label("Your age").
+		Description("Used to pick the right channels").
+		SetComponent(cb.TextInput("age", "Age").Build())
+	if err := lb.Err(); err != nil {
+		t.Fatal(err)
+	}
+	label := lb.Build()
+
+	b, err := json.Marshal(label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MessageComponentFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := decoded.(*Label)
+	if !ok {
+		t.Fatalf("decoded %T, want *Label", decoded)
+	}
+	if got.Text != "Your age" || got.Description != "Used to pick the right channels" {
+		t.Errorf("unexpected label %+v", got)
+	}
+	input, ok := got.Component.(*TextInput)
+	if !ok || input.CustomID != "age" {
+		t.Errorf("unexpected wrapped component %#v", got.Component)
+	}
+
+	if err := cb.Label("Nested").SetComponent(label).Err(); !errors.Is(err, ErrInvalidChild) {
+		t.Errorf("nesting labels: got %v, want ErrInvalidChild", err)
+	}
+}
+
+func TestValidateLabel(t *testing.T) {
+	cb := NewBuilder()
+	input := cb.TextInput("age", "Age").Build()
+	if err := ValidateComponent(cb.Label("Your age").SetComponent(input).Build()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for name, label := range map[string]Label{
+		"empty text":       {Component: input},
+		"long text":        {Text: strings.Repeat("a", 46), Component: input},
+		"long description": {Text: "Your age", Description: strings.Repeat("a", 101), Component: input},
+		"no component":     {Text: "Your age"},
+	} {
+		if err := ValidateComponent(label); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	prefilled := cb.TextInput("age", "Age").Validation("[0-9]+").Value("abc").Build()
+	modal := cb.Modal("profile", "Profile").
+		AddComponent(cb.Label("Your age").SetComponent(prefilled).Build()).
+		Build()
+	if err := ValidateComponent(modal); err == nil || !strings.Contains(err.Error(), "validation pattern") {
+		t.Errorf("expected a prefill error for the labelled input, got %v", err)
+	}
+	modal = cb.Modal("profile", "Profile").
+		AddComponent(Label{Text: "Note", Component: NewTextDisplay("hi")}).
+		Build()
+	if err := ValidateComponent(modal); err == nil {
+		t.Error("expected an error for a label without an input")
+	}
+}
+
+func TestButtonEmojis(t *testing.T) {
+	cb := NewBuilder()
+	row := QuickButtons(
+		cb.Button("").Secondary().CustomID("page_prev").Emoji("◀️", "", false).Build(),
+		cb.Button("2/5").Secondary().CustomID("page_current").Build(),
+		cb.Button("").Secondary().CustomID("page_next").Emoji("▶️", "", false).Build(),
+	)
+
+	emojis := ButtonEmojis(NewContainer(row))
+	if len(emojis) != 2 || emojis[0].Name != "◀️" || emojis[1].Name != "▶️" {
+		t.Errorf("ButtonEmojis = %+v, want ◀️ and ▶️", emojis)
+	}
+}
+
+func TestStrictDuplicateOptionEmojiWarning(t *testing.T) {
+	fire := &ComponentEmoji{Name: "fire", ID: "123456789012345678"}
+	red, orange := QuickOption("Red", "red", ""), QuickOption("Orange", "orange", "")
+	red.Emoji, orange.Emoji = fire, fire
+	menu := QuickSelectMenu("color", "Pick a color", red, orange, QuickOption("Blue", "blue", ""))
+
+	if issues := ValidateReport(menu); len(issues) != 0 {
+		t.Errorf("unexpected issues without strict validation: %v", issues)
+	}
+
+	issues := ValidateReport(menu, WithStrictValidation(true))
+	if len(issues) != 1 || issues[0].Severity != IssueWarning || !strings.Contains(issues[0].Message, `"red" and "orange"`) {
+		t.Errorf("expected a duplicate emoji warning, got %v", issues)
+	}
+}
+
+func TestEmojiShortcode(t *testing.T) {
+	SetEmojiResolver(func(code string) (ComponentEmoji, bool) {
+		if code == ":fire:" {
+			return ComponentEmoji{Name: "🔥"}, true
+		}
+		return ComponentEmoji{}, false
+	})
+	defer SetEmojiResolver(nil)
+
+	bb := NewBuilder().Button("Hot").CustomID("hot").EmojiShortcode(":fire:")
+	if err := bb.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if button := bb.Build(); button.Emoji == nil || button.Emoji.Name != "🔥" {
+		t.Errorf("unexpected emoji %+v", button.Emoji)
+	}
+
+	if err := NewBuilder().Button("Cold").EmojiShortcode(":ice:").Err(); err == nil {
+		t.Error("expected an error for an unknown shortcode")
+	}
+}
+
+func TestWebhookComponents(t *testing.T) {
+	body := WebhookComponents(QuickConfirmDialog("delete"))
+	body["content"] = "Are you sure?"
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Content    string            `json:"content"`
+		Components []json.RawMessage `json:"components"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Content != "Are you sure?" || len(decoded.Components) != 1 {
+		t.Fatalf("unexpected body %s", b)
+	}
+	if _, err := MessageComponentFromJSON(decoded.Components[0]); err != nil {
+		t.Errorf("components entry did not decode: %v", err)
+	}
+
+	if b, _ := json.Marshal(WebhookComponents()); string(b) != `{"components":[]}` {
+		t.Errorf("empty body = %s", b)
+	}
+}
+
+func TestContainerWithoutContentWarning(t *testing.T) {
+	blank := NewContainer(Separator{Divider: true}, NewTextDisplay(" "), Separator{Spacing: SeparatorSpacingLarge})
+
+	found := false
+	for _, issue := range ValidateReport(blank) {
+		found = found || (issue.Severity == IssueWarning && strings.Contains(issue.Message, "blank space"))
+	}
+	if !found {
+		t.Error("expected a warning for a container without content")
+	}
+
+	filled := NewContainer(Separator{Divider: true}, NewTextDisplay("Rules"))
+	for _, issue := range ValidateReport(filled) {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	source := QuickConfirmDialog("delete")
+	frozen := Freeze(source)
+
+	// The frozen copy no longer shares anything with its source
+	source.Components[0] = QuickButton("Changed", "changed", PrimaryButton)
+	if componentsEqual(frozen, source) {
+		t.Fatal("frozen tree changed with its source")
+	}
+
+	before, err := json.Marshal(frozen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := Clone(frozen).(ActionsRow)
+	edited.Components[0] = QuickButton("Sure", "delete_sure", SuccessButton)
+	edited.Components = append(edited.Components, QuickButton("Later", "delete_later", SecondaryButton))
+
+	after, err := json.Marshal(frozen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("frozen tree changed from %s to %s", before, after)
+	}
+}
+
+func TestQuickNumberButtons(t *testing.T) {
+	rows := QuickNumberButtons("quiz", 7)
+	if len(rows) != 2 || len(rows[0].Components) != 5 || len(rows[1].Components) != 2 {
+		t.Fatalf("unexpected row packing %+v", rows)
+	}
+	first := rows[0].Components[0].(Button)
+	last := rows[1].Components[1].(Button)
+	if first.Label != "1️⃣" || first.CustomID != "quiz_1" {
+		t.Errorf("unexpected first button %+v", first)
+	}
+	if last.Label != "7️⃣" || last.CustomID != "quiz_7" {
+		t.Errorf("unexpected last button %+v", last)
+	}
+
+	if rows := QuickNumberButtons("quiz", 12); len(rows) != 2 || rows[1].Components[4].(Button).Label != "🔟" {
+		t.Errorf("expected 10 buttons ending in 🔟, got %+v", rows)
+	}
+}
+
+func TestValidateEmptyOptionValue(t *testing.T) {
+	menu := QuickSelectMenu("color", "Pick a color", QuickOption("Red", "red", ""), QuickOption("Blue", "", ""))
+	if err := ValidateComponent(menu); err == nil || !strings.Contains(err.Error(), "option 1 must have a value") {
+		t.Errorf("expected an empty value error, got %v", err)
+	}
+
+	menu.Options[1].Value = "blue"
+	if err := ValidateComponent(menu); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestChoicesToComponents(t *testing.T) {
+	options := make([]SelectMenuOption, 10)
+	for i := range options {
+		options[i] = QuickOption(fmt.Sprintf("Choice %d", i+1), fmt.Sprint(i+1), "")
+	}
+
+	buttons := ChoicesToComponents("vote", options[:3], 5)
+	if len(buttons) != 1 {
+		t.Fatalf("got %d rows for 3 choices, want 1", len(buttons))
+	}
+	row := buttons[0].(ActionsRow)
+	if len(row.Components) != 3 || row.Components[2].(Button).CustomID != "vote_3" {
+		t.Errorf("unexpected button row %+v", row)
+	}
+
+	menu := ChoicesToComponents("vote", options, 5)
+	if len(menu) != 1 {
+		t.Fatalf("got %d components for 10 choices, want 1", len(menu))
+	}
+	selectMenu, ok := menu[0].(ActionsRow).Components[0].(SelectMenu)
+	if !ok || selectMenu.CustomID != "vote" || len(selectMenu.Options) != 10 {
+		t.Errorf("unexpected select row %+v", menu[0])
+	}
+}
+
+func TestNewBuilderWithTranslator(t *testing.T) {
+	french := map[string]string{"confirm": "Confirm!", "profile": "Profil"}
+	cb := NewBuilderWithTranslator(func(key string) string {
+		if text, ok := french[key]; ok {
+			return text
+		}
+		return key
+	})
+
+	if button := cb.Button("confirm").CustomID("ok").Build(); button.Label != "Confirm!" {
+		t.Errorf("button label = %q, want %q", button.Label, "Confirm!")
+	}
+	if modal := cb.Modal("profile", "profile").Build(); modal.Title != "Profil" || modal.CustomID != "profile" {
+		t.Errorf("unexpected modal %+v", modal)
+	}
+	if button := NewBuilder().Button("confirm").Build(); button.Label != "confirm" {
+		t.Errorf("plain builder translated label to %q", button.Label)
+	}
+}
+
+func TestButtonBadgeText(t *testing.T) {
+	button := NewBuilder().Button("Shop").CustomID("shop").BadgeText("NEW").Build()
+	if button.BadgeText != "NEW" || button.Badge != nil {
+		t.Errorf("unexpected badges %v and %q", button.Badge, button.BadgeText)
+	}
+	b, err := json.Marshal(button)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"badge_text":"NEW"`) || strings.Contains(string(b), `"badge":`) {
+		t.Errorf("unexpected JSON %s", b)
+	}
+	if err := ValidateComponent(button); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	both := NewBuilder().Button("Shop").CustomID("shop").Badge(3).BadgeText("NEW").Build()
+	if err := ValidateComponent(both); err == nil {
+		t.Error("expected an error for a button with both badges")
+	}
+}
+
+func TestValidateModal(t *testing.T) {
+	cb := NewBuilder()
+	modal := cb.Modal("profile", "Edit profile").
+		AddTextInput(cb.TextInput("name", "Name").MaxLength(32).Build()).
+		AddTextInput(cb.TextInput("bio", "About you").Paragraph().Build()).
+		Build()
+	if err := ValidateModal(modal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	untitled := modal
+	untitled.Title = ""
+	if err := ValidateModal(untitled); err == nil {
+		t.Error("expected an error for a modal without a title")
+	}
+
+	duplicate := cb.Modal("profile", "Edit profile").
+		AddTextInput(cb.TextInput("name", "Name").Build()).
+		AddTextInput(cb.TextInput("name", "Nickname").Build()).
+		Build()
+	if err := ValidateModal(duplicate); err == nil || !strings.Contains(err.Error(), "components[1].components[0]") {
+		t.Errorf("expected a duplicate ID error on the second input, got %v", err)
+	}
+}
+
+func TestWalkWithParent(t *testing.T) {
+	button := QuickButton("Yes", "yes", SuccessButton)
+	row := QuickButtons(button)
+	container := NewContainer(row)
+
+	parents := make(map[string]MessageComponent)
+	visited := 0
+	WalkWithParent(container, func(parent, child MessageComponent) bool {
+		visited++
+		if visited == 1 && parent != nil {
+			t.Errorf("root reported parent %#v", parent)
+		}
+		if id := componentCustomID(child); id != "" {
+			parents[id] = parent
+		}
+		return true
+	})
+	if visited != 3 {
+		t.Errorf("visited %d components, want 3", visited)
+	}
+	if parent, ok := parents["yes"].(ActionsRow); !ok || !componentsEqual(parent, row) {
+		t.Errorf("button parent = %#v, want the row", parents["yes"])
+	}
+
+	visited = 0
+	WalkWithParent(container, func(parent, child MessageComponent) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("walk continued after returning false, visited %d", visited)
+	}
+}
+
+func TestRedactMasked(t *testing.T) {
+	cb := NewBuilder()
+	modal := cb.Modal("login", "Log in").
+		AddTextInput(cb.TextInput("user", "Username").Build()).
+		AddTextInput(cb.TextInput("password", "Password").Masked(true).Build()).
+		Build()
+	values := map[string]string{"user": "alice", "password": "hunter2"}
+
+	redacted := RedactMasked(modal, values)
+	if redacted["user"] != "alice" || redacted["password"] != "***" {
+		t.Errorf("unexpected redacted values %v", redacted)
+	}
+	if values["password"] != "hunter2" {
+		t.Error("RedactMasked modified its input")
+	}
+}
+
+func TestLayoutBuilderIDCollision(t *testing.T) {
+	cb := NewBuilder()
+	lb := cb.Layout().
+		Add(NewActionsRow(cb.SelectMenu("color").AddOption("Red", "red", "").ID(5).Build())).
+		Add(NewActionsRow(QuickButtonWithID("OK", "ok", PrimaryButton, 6)))
+	if err := lb.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lb.Add(NewActionsRow(cb.SelectMenu("size").AddOption("Small", "s", "").ID(5).Build()))
+	err := lb.Err()
+	if err == nil || !strings.Contains(err.Error(), "components[2].components[0]") || !strings.Contains(err.Error(), "components[0].components[0]") {
+		t.Errorf("expected a collision error naming both paths, got %v", err)
+	}
+	if n := len(lb.Build()); n != 2 {
+		t.Errorf("layout has %d components, want 2", n)
+	}
+}
+
+func TestEditPatch(t *testing.T) {
+	old := []MessageComponent{
+		NewTextDisplay("Delete this message?"),
+		QuickConfirmDialog("delete"),
+		QuickPagination("page", 1, 3),
+	}
+	new := Clone(NewContainer(old...)).(Container).Components
+	row := new[1].(ActionsRow)
+	yes := row.Components[0].(Button)
+	yes.Label = "Sure"
+	row.Components[0] = yes
+
+	b, err := EditPatch(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(b, &patch); err != nil {
+		t.Fatalf("patch is not an object: %s", b)
+	}
+	if len(patch) != 1 || !strings.Contains(string(patch["1"]), `"label":"Sure"`) {
+		t.Errorf("unexpected patch %s", b)
+	}
+
+	b, err = EditPatch(old, new[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full []json.RawMessage
+	if err := json.Unmarshal(b, &full); err != nil || len(full) != 2 {
+		t.Errorf("expected the full array after a structural change, got %s", b)
+	}
+}
+
+func TestEditComponents(t *testing.T) {
+	old := []MessageComponent{
+		NewTextDisplay("Delete this message?"),
+		QuickConfirmDialog("delete"),
+	}
+	new := Clone(NewContainer(old...)).(Container).Components
+
+	b, err := EditComponents(old, new)
+	if err != nil || b != nil {
+		t.Errorf("expected nothing for unchanged components, got %s, %v", b, err)
+	}
+
+	new[0] = NewTextDisplay("Really delete this message?")
+	b, err = EditComponents(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full []json.RawMessage
+	if err := json.Unmarshal(b, &full); err != nil || len(full) != 2 {
+		t.Errorf("expected the full array after a change, got %s", b)
+	}
+}
+
+func TestValidateTextDisplayLength(t *testing.T) {
+	if err := ValidateComponent(NewTextDisplay(strings.Repeat("é", 2000))); err == nil {
+		t.Error("expected an error for 2000 characters of content")
+	}
+	if err := ValidateComponent(NewTextDisplay(strings.Repeat("é", 1024))); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+}
+
+func TestActionsRowButtonSize(t *testing.T) {
+	cb := NewBuilder()
+	row := cb.ActionsRow().
+		AddButton(QuickButton("Before", "before", PrimaryButton)).
+		ButtonSize(ButtonSizeLarge).
+		AddButton(QuickButton("After", "after", SecondaryButton)).
+		Build()
+
+	if len(row.Components) != 2 {
+		t.Fatalf("got %d buttons, want 2", len(row.Components))
+	}
+	for _, c := range row.Components {
+		if b := c.(Button); b.Size != ButtonSizeLarge {
+			t.Errorf("button %q has size %q, want large", b.Label, b.Size)
+		}
+	}
+}
+
+func TestValidateSelectDefaultCount(t *testing.T) {
+	red, blue := QuickOption("Red", "red", ""), QuickOption("Blue", "blue", "")
+	red.Default, blue.Default = true, true
+	menu := QuickSelectMenu("color", "Pick a color", red, blue)
+	if err := ValidateComponent(menu); err == nil || !strings.Contains(err.Error(), "2 defaults") {
+		t.Errorf("expected a default count error, got %v", err)
+	}
+
+	menu.MaxValues = 2
+	if err := ValidateComponent(menu); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRemapIDs(t *testing.T) {
+	tree := AssignIDs(NewContainer(NewTextDisplay("Delete?"), QuickConfirmDialog("delete")))
+	remapped := RemapIDs(tree, 100)
+
+	var before, after []int
+	walkComponents(tree, "", nil, func(path string, parent, c MessageComponent) bool {
+		id, _ := componentID(c)
+		before = append(before, id)
+		return true
+	})
+	walkComponents(remapped, "", nil, func(path string, parent, c MessageComponent) bool {
+		id, _ := componentID(c)
+		after = append(after, id)
+		return true
+	})
+	if len(before) != 5 || len(after) != len(before) {
+		t.Fatalf("unexpected IDs %v and %v", before, after)
+	}
+	for i := range before {
+		if after[i] != before[i]+100 {
+			t.Errorf("ID %d became %d, want %d", before[i], after[i], before[i]+100)
+		}
+	}
+}
+
+func TestQuickPrevNext(t *testing.T) {
+	row := QuickPrevNext("page", false, true)
+	if len(row.Components) != 2 {
+		t.Fatalf("got %d buttons, want 2", len(row.Components))
+	}
+	prev, next := row.Components[0].(Button), row.Components[1].(Button)
+	if !prev.Disabled || prev.CustomID != "page_prev" {
+		t.Errorf("unexpected prev button %+v", prev)
+	}
+	if next.Disabled || next.CustomID != "page_next" {
+		t.Errorf("unexpected next button %+v", next)
+	}
+}
+
+func TestValidateButtonGroup(t *testing.T) {
+	var buttons []Button
+	for i := 0; i < 26; i++ {
+		buttons = append(buttons, QuickButton(fmt.Sprint(i), fmt.Sprintf("b%d", i), SecondaryButton))
+	}
+	if err := ValidateComponent(ButtonsToGroup(buttons...)); err == nil || !strings.Contains(err.Error(), "maximum 25") {
+		t.Errorf("expected an overflow error, got %v", err)
+	}
+	if err := ValidateComponent(ButtonsToGroup(buttons[:25]...)); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+
+	mixed := ButtonsToGroup(buttons[0], NewBuilder().Button("Docs").Link("https://example.com").Build())
+	issues := ValidateReport(mixed, WithStrictValidation(true))
+	if len(issues) != 1 || issues[0].Severity != IssueWarning || !strings.Contains(issues[0].Message, "mixes link") {
+		t.Errorf("expected a mixed styles warning, got %v", issues)
+	}
+}
+
+func TestQuickFAQ(t *testing.T) {
+	faq := QuickFAQ("faq", []FAQEntry{
+		{Question: "How do I join?", Answer: "Click the invite link."},
+		{Question: "Is it free?", Answer: "Yes."},
+	})
+	if faq.CustomID != "faq" || len(faq.Items) != 2 {
+		t.Fatalf("unexpected accordion %+v", faq)
+	}
+	if faq.Items[0].Title != "How do I join?" || faq.Items[1].Title != "Is it free?" {
+		t.Errorf("unexpected titles %q and %q", faq.Items[0].Title, faq.Items[1].Title)
+	}
+	for _, item := range faq.Items {
+		if _, ok := item.Content.(TextDisplay); !ok {
+			t.Errorf("item %s content is %T, want TextDisplay", item.ID, item.Content)
+		}
+	}
+	if err := ValidateComponent(faq); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTruncateDescriptions(t *testing.T) {
+	long := strings.Repeat("ü", 200)
+	menu := NewBuilder().SelectMenu("lang").
+		TruncateDescriptions(true).
+		AddOption("German", "de", long).
+		Build()
+	if n := utf8.RuneCountInString(menu.Options[0].Description); n != 100 {
+		t.Errorf("description is %d runes, want 100", n)
+	}
+
+	menu = NewBuilder().SelectMenu("lang").AddOption("German", "de", long).Build()
+	if menu.Options[0].Description != long {
+		t.Error("description was truncated without TruncateDescriptions")
+	}
+}
+
+func TestNeedsHandler(t *testing.T) {
+	cb := NewBuilder()
+	links := QuickButtons(
+		cb.Button("Docs").Link("https://example.com/docs").Build(),
+		cb.Button("Site").Link("https://example.com").Build(),
+	)
+	if NeedsHandler(links) {
+		t.Error("a row of link buttons should not need a handler")
+	}
+	if !NeedsHandler(NewContainer(links, QuickConfirmDialog("delete"))) {
+		t.Error("a confirm dialog should need a handler")
+	}
+}
+
+func TestActionsRowMarshalJSONFast(t *testing.T) {
+	cb := NewBuilder()
+	rows := []ActionsRow{
+		QuickConfirmDialog("delete"),
+		QuickPagination("page", 2, 5),
+		QuickButtons(
+			cb.Button(`Say "hi" <b>&</b>`).CustomID("quote\\path").Tooltip("line\nbreak\u2028").Build(),
+			cb.Button("Docs").Link("https://example.com/?a=1&b=2").Size(ButtonSizeSmall).Build(),
+			QuickButtonWithID("Bad \xff byte", "bad", DangerButton, 4),
+			Button{Label: "No style", CustomID: "zero", Loading: true, BadgeText: "NEW", Disabled: true},
+		),
+		{Components: []MessageComponent{NewButton()}, ID: 9},
+		{Components: []MessageComponent{}},
+		{},
+		// falls back because of the emoji
+		QuickButtons(cb.Button("").CustomID("fire").Emoji("🔥", "", false).Build()),
+	}
+
+	for i, row := range rows {
+		want, err := json.Marshal(row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := row.MarshalJSONFast()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("row %d:\n got %s\nwant %s", i, got, want)
+		}
+	}
+}
+
+// A field added to Button but not to writeFastButton would silently go
+// missing from MarshalJSONFast, so set every exported field and compare.
+func TestMarshalJSONFastCoversButtonFields(t *testing.T) {
+	// the fast path falls back to MarshalJSON when these are set
+	fallback := map[string]bool{"Emoji": true, "Badge": true, "Extra": true}
+
+	var button Button
+	v := reflect.ValueOf(&button).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" || fallback[field.Name] {
+			continue
+		}
+		switch f := v.Field(i); f.Kind() {
+		case reflect.String:
+			f.SetString(strings.ToLower(field.Name))
+		case reflect.Int:
+			f.SetInt(int64(i + 1))
+		case reflect.Uint:
+			f.SetUint(uint64(i + 1))
+		case reflect.Bool:
+			f.SetBool(true)
+		default:
+			t.Fatalf("Button.%s has kind %s, which writeFastButton doesn't handle", field.Name, f.Kind())
+		}
+	}
+
+	row := QuickButtons(button)
+	want, err := row.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := row.MarshalJSONFast()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("MarshalJSONFast = %s\nwant %s", got, want)
+	}
+}
+
+func BenchmarkActionsRowMarshalJSON(b *testing.B) {
+	row := QuickPagination("page", 2, 5)
+	b.Run("standard", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(row); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("fast", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := row.MarshalJSONFast(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestStreamCustomIDs(t *testing.T) {
+	cb := NewBuilder()
+	layout := []MessageComponent{
+		QuickConfirmDialog("delete"),
+		NewActionsRow(cb.SelectMenu("color").AddOption("custom_id", "custom_id", "").Build()),
+		NewContainer(NewTextDisplay(`{"custom_id":"fake"}`), QuickPrevNext("page", true, true)),
+		QuickConfirmDialog("delete"),
+	}
+	b, err := json.Marshal(layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := StreamCustomIDs(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"delete_yes", "delete_no", "color", "page_prev", "page_next"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("StreamCustomIDs = %v, want %v", ids, want)
+	}
+
+	if _, err := StreamCustomIDs(strings.NewReader(`[{"custom_id":`)); err == nil {
+		t.Error("expected an error for a truncated payload")
+	}
+}
+
+func TestValidateMentionableSelect(t *testing.T) {
+	menu := SelectMenu{MenuType: MentionableSelectMenu, CustomID: "who"}
+	if err := ValidateComponent(menu); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	withOptions := menu
+	withOptions.Options = []SelectMenuOption{QuickOption("Everyone", "everyone", "")}
+	if err := ValidateComponent(withOptions); err == nil || !strings.Contains(err.Error(), "options") {
+		t.Errorf("expected an options error, got %v", err)
+	}
+
+	withChannels := menu
+	withChannels.ChannelTypes = []ChannelType{0}
+	if err := ValidateComponent(withChannels); err == nil || !strings.Contains(err.Error(), "channel types") {
+		t.Errorf("expected a channel types error, got %v", err)
+	}
+}
+
+func TestSelectMenuSingle(t *testing.T) {
+	menu := NewBuilder().SelectMenu("color").AddOption("Red", "red", "").Single().Build()
+	if menu.MinValues == nil || *menu.MinValues != 1 || menu.MaxValues != 1 {
+		t.Errorf("unexpected bounds %v and %d", menu.MinValues, menu.MaxValues)
+	}
+}
+
+func TestContainerAccentColorWarning(t *testing.T) {
+	container := NewContainer(NewTextDisplay("Server rules"))
+
+	for _, tc := range []struct {
+		color int
+		warn  bool
+	}{
+		{0x000000, true},
+		{0xffffff, true},
+		{0x5865f2, false},
+	} {
+		color := tc.color
+		container.AccentColor = &color
+		warned := false
+		for _, issue := range ValidateReport(container) {
+			warned = warned || (issue.Severity == IssueWarning && strings.Contains(issue.Message, "accent color"))
+		}
+		if warned != tc.warn {
+			t.Errorf("accent %06x: warned = %v, want %v", tc.color, warned, tc.warn)
+		}
+	}
+}
+
+func TestCycleButtonStyle(t *testing.T) {
+	button := QuickButton("Mode", "mode", PrimaryButton)
+	var seen []ButtonStyle
+	for i := 0; i < 2; i++ {
+		button = CycleButtonStyle(button, PrimaryButton, SecondaryButton)
+		seen = append(seen, button.Style)
+	}
+	if seen[0] != SecondaryButton || seen[1] != PrimaryButton {
+		t.Errorf("cycled through %v, want secondary then primary", seen)
+	}
+
+	if got := CycleButtonStyle(QuickButton("Mode", "mode", DangerButton), PrimaryButton, SecondaryButton); got.Style != PrimaryButton {
+		t.Errorf("unlisted style cycled to %v, want primary", got.Style)
+	}
+}
+
+func TestUnknownFieldsSurviveRoundTrip(t *testing.T) {
+	raw := []byte(`[{"type":1,"components":[` +
+		`{"type":2,"label":"Go","style":1,"custom_id":"go","glow":{"color":"gold"}},` +
+		`{"type":3,"custom_id":"pick","options":[{"label":"A","value":"a"}],"max_height":3}` +
+		`]}]`)
+	components, err := ParseMessageComponents(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := components[0].(*ActionsRow)
+	button := row.Components[0].(*Button)
+	if string(button.Extra["glow"]) != `{"color":"gold"}` || len(button.Extra) != 1 {
+		t.Fatalf("unexpected button extras %v", button.Extra)
+	}
+
+	b, err := json.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"glow":{"color":"gold"}`, `"max_height":3`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("re-marshaled row lost %s: %s", want, b)
+		}
+	}
+
+	// Extras can't override modeled fields
+	button.Extra["label"] = json.RawMessage(`"Hijacked"`)
+	if b, _ := json.Marshal(button); strings.Contains(string(b), "Hijacked") {
+		t.Errorf("extra field overrode the label: %s", b)
+	}
+	if b, _ := json.Marshal(QuickButton("Go", "go", PrimaryButton)); strings.Contains(string(b), "Extra") {
+		t.Errorf("Extra leaked into the JSON: %s", b)
+	}
+}
+
+func TestSeparatorBuilder(t *testing.T) {
+	separator := NewBuilder().Separator().Large().Divider().Build()
+	if separator.Spacing != SeparatorSpacingLarge || !separator.Divider {
+		t.Errorf("unexpected separator %+v", separator)
+	}
+	if small := NewBuilder().Separator().Small().Build(); small.Spacing != SeparatorSpacingSmall || small.Divider {
+		t.Errorf("unexpected separator %+v", small)
+	}
+}
+
+func TestValidateForSurfaceTextInput(t *testing.T) {
+	cb := NewBuilder()
+	row := NewActionsRow(cb.TextInput("name", "Name").Build())
+	for _, s := range []Surface{SurfaceMessage, SurfaceInteractionResponse} {
+		if err := ValidateForSurface(row, s); err == nil || !strings.Contains(err.Error(), "only allowed in modals") {
+			t.Errorf("%s: expected a text input error, got %v", s, err)
+		}
+	}
+
+	modal := cb.Modal("profile", "Profile").AddTextInput(cb.TextInput("name", "Name").Build()).Build()
+	if err := ValidateForSurface(modal, SurfaceModal); err != nil {
+		t.Errorf("unexpected error in a modal: %v", err)
+	}
+}
+
+func TestSelectMenuWithHint(t *testing.T) {
+	menu := NewBuilder().SelectMenu("color").
+		AddOption("Red", "red", "").
+		AddOption("Blue", "blue", "").
+		WithHint("Please choose a color").
+		Build()
+	if menu.Placeholder != "Please choose a color" {
+		t.Errorf("placeholder = %q", menu.Placeholder)
+	}
+	for _, option := range menu.Options {
+		if option.Default {
+			t.Errorf("option %q is selected", option.Value)
+		}
+	}
+
+	if issues := ValidateReport(menu, WithStrictValidation(true)); len(issues) != 0 {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+}
+
+func TestValidateTopLevelMixing(t *testing.T) {
+	components := []MessageComponent{
+		QuickConfirmDialog("delete"),
+		NewContainer(NewTextDisplay("Are you sure?")),
+	}
+	err := ValidateTopLevelMixing(components, false)
+	if err == nil || !strings.Contains(err.Error(), "components[1]") {
+		t.Errorf("expected an error for the container, got %v", err)
+	}
+	if err := ValidateTopLevelMixing(components, true); err != nil {
+		t.Errorf("unexpected error with v2: %v", err)
+	}
+	if err := ValidateTopLevelMixing(components[:1], false); err != nil {
+		t.Errorf("unexpected error for legacy rows: %v", err)
+	}
+}
+
+func TestLoadMoreButton(t *testing.T) {
+	button, err := LoadMoreButton("feed", "c2luY2U9MTIz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if button.CustomID != "feed:more:c2luY2U9MTIz" || button.Style != SecondaryButton {
+		t.Errorf("unexpected button %+v", button)
+	}
+	if err := ValidateComponent(button); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cursor, ok := ParseLoadMore("feed", button.CustomID)
+	if !ok || cursor != "c2luY2U9MTIz" {
+		t.Errorf("ParseLoadMore = %q, %v", cursor, ok)
+	}
+	if _, ok := ParseLoadMore("other", button.CustomID); ok {
+		t.Error("ParseLoadMore accepted a different prefix")
+	}
+
+	if _, err := LoadMoreButton("feed", strings.Repeat("x", 95)); err == nil || !strings.Contains(err.Error(), "custom ID") {
+		t.Errorf("expected a custom ID length error, got %v", err)
+	}
+	if _, err := LoadMoreButton("feed", strings.Repeat("é", 90)); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+}
+
+func TestValidateCustomIDLength(t *testing.T) {
+	if err := ValidateComponent(QuickButton("Go", strings.Repeat("é", 100), PrimaryButton)); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+	menu := QuickSelectMenu(strings.Repeat("x", 101), "Pick one", QuickOption("One", "1", ""))
+	if err := ValidateComponent(menu); err == nil || !strings.Contains(err.Error(), "custom ID") {
+		t.Errorf("expected a custom ID length error, got %v", err)
+	}
+}
+
+func TestValidateTextDisplayCount(t *testing.T) {
+	var texts []MessageComponent
+	for i := 0; i < 10; i++ {
+		texts = append(texts, NewTextDisplay(fmt.Sprintf("Line %d", i+1)))
+	}
+	components := []MessageComponent{NewContainer(texts...)}
+	if err := ValidateTextDisplayCount(components); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+
+	components = append(components, NewTextDisplay("One too many"))
+	if err := ValidateTextDisplayCount(components); err == nil || !strings.Contains(err.Error(), "11 text displays") {
+		t.Errorf("expected a text display count error, got %v", err)
+	}
+}
+
+func TestSelectMenuClearDefaults(t *testing.T) {
+	red := QuickOption("Red", "red", "")
+	red.Default = true
+	builder := NewBuilder().SelectMenu("color")
+	builder.menu.Options = []SelectMenuOption{red, QuickOption("Blue", "blue", "")}
+	builder.menu.DefaultValues = []SelectMenuDefaultValue{{ID: "123", Type: SelectMenuDefaultValueUser}}
+
+	menu := builder.ClearDefaults().Build()
+	for _, option := range menu.Options {
+		if option.Default {
+			t.Errorf("option %q is still a default", option.Value)
+		}
+	}
+	if len(menu.DefaultValues) != 0 {
+		t.Errorf("default values remain: %+v", menu.DefaultValues)
+	}
+}
+
+func TestSectionBuilder(t *testing.T) {
+	cb := NewBuilder()
+	sb := cb.Section().
+		AddText("## Weekly event").
+		AddText("Starts Friday at 18:00").
+		Accessory(QuickButton("Join", "join", SuccessButton))
+	if err := sb.Err(); err != nil {
+		t.Fatal(err)
+	}
+	section := sb.Build()
+	if err := ValidateComponent(section); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := json.Marshal(section)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MessageComponentFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decoded.(*Section)
+	if len(got.Components) != 2 {
+		t.Errorf("decoded %d text displays, want 2", len(got.Components))
+	}
+	if accessory, ok := got.Accessory.(*Button); !ok || accessory.CustomID != "join" {
+		t.Errorf("unexpected accessory %#v", got.Accessory)
+	}
+
+	if err := cb.Section().AddText("Hi").Accessory(NewTextDisplay("no")).Err(); !errors.Is(err, ErrInvalidChild) {
+		t.Errorf("text accessory: got %v, want ErrInvalidChild", err)
+	}
+	if err := ValidateComponent(Section{Accessory: QuickButton("Join", "join", SuccessButton)}); err == nil {
+		t.Error("expected an error for a section without text")
+	}
+	if err := ValidateComponent(Section{
+		Components: []MessageComponent{TextDisplay{Content: "Hi"}},
+		Accessory:  QuickSelectMenu("pick", "", QuickOption("A", "a", "")),
+	}); err == nil {
+		t.Error("expected an error for a select menu accessory")
+	}
+}
+
+func TestToDiscordgo(t *testing.T) {
+	button := NewBuilder().Button("Go").CustomID("go").Tooltip("Start").BadgeText("NEW").Size(ButtonSizeLarge).Build()
+	raw, err := ToDiscordgo(button)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The documented fields of upstream's Button
+	var upstream struct {
+		Label    string          `json:"label"`
+		Style    ButtonStyle     `json:"style"`
+		Disabled bool            `json:"disabled"`
+		Emoji    *ComponentEmoji `json:"emoji,omitempty"`
+		URL      string          `json:"url,omitempty"`
+		CustomID string          `json:"custom_id,omitempty"`
+		SKUID    string          `json:"sku_id,omitempty"`
+		ID       int             `json:"id,omitempty"`
+		Type     ComponentType   `json:"type"`
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&upstream); err != nil {
+		t.Fatalf("%s does not fit upstream's button: %v", raw, err)
+	}
+	if upstream.Label != "Go" || upstream.CustomID != "go" || upstream.Type != ButtonComponent {
+		t.Errorf("unexpected button %+v", upstream)
+	}
+
+	tabs := NewBuilder().Tabs("settings").AddTab("general", "General", NewTextDisplay("Hi")).Build()
+	if _, err := ToDiscordgo(NewContainer(tabs)); err == nil || !strings.Contains(err.Error(), "components[0]") {
+		t.Errorf("expected an error for tabs, got %v", err)
+	}
+}
+
+func TestModalRoundTrip(t *testing.T) {
+	cb := NewBuilder()
+	modal := cb.Modal("profile", "Profile").
+		AddTextInput(cb.TextInput("name", "Name").Build()).
+		AddTextInput(cb.TextInput("bio", "About you").Paragraph().Build()).
+		Build()
+
+	b, err := json.Marshal(modal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MessageComponentFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := decoded.(*Modal)
+	if !ok {
+		t.Fatalf("decoded %T, want *Modal", decoded)
+	}
+	if got.CustomID != "profile" || len(got.Components) != 2 {
+		t.Fatalf("unexpected modal %+v", got)
+	}
+	for i, want := range []string{"name", "bio"} {
+		row, ok := got.Components[i].(*ActionsRow)
+		if !ok || len(row.Components) != 1 {
+			t.Fatalf("component %d is %#v, want a row", i, got.Components[i])
+		}
+		if input, ok := row.Components[0].(*TextInput); !ok || input.CustomID != want {
+			t.Errorf("row %d holds %#v, want text input %q", i, row.Components[0], want)
+		}
+	}
+}
+
+func TestTabsAndAccordionRoundTrip(t *testing.T) {
+	cb := NewBuilder()
+	layout := NewContainer(
+		cb.Tabs("settings").AddTab("general", "General", QuickButtons(QuickButton("Save", "save", SuccessButton))).Build(),
+		QuickFAQ("faq", []FAQEntry{{Question: "Why?", Answer: "Because."}}),
+	)
+
+	b, err := json.Marshal(layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MessageComponentFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := decoded.(*Container)
+	tabs, ok := container.Components[0].(*Tabs)
+	if !ok || len(tabs.TabList) != 1 {
+		t.Fatalf("unexpected tabs %#v", container.Components[0])
+	}
+	if _, ok := tabs.TabList[0].Content.(*ActionsRow); !ok {
+		t.Errorf("tab content is %T, want *ActionsRow", tabs.TabList[0].Content)
+	}
+	accordion, ok := container.Components[1].(*Accordion)
+	if !ok || len(accordion.Items) != 1 {
+		t.Fatalf("unexpected accordion %#v", container.Components[1])
+	}
+	if _, ok := accordion.Items[0].Content.(*TextDisplay); !ok {
+		t.Errorf("accordion content is %T, want *TextDisplay", accordion.Items[0].Content)
+	}
+	if !componentsEqual(decoded, layout) {
+		t.Error("layout changed across the round trip")
+	}
+}
@@ -3,6 +3,7 @@ module github.com/nitrix4ly/discordgo-exts
 go 1.13
 
 require (
+	github.com/bwmarrin/discordgo v0.29.0
 	github.com/gorilla/websocket v1.4.2
 	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b
 )